@@ -0,0 +1,362 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var battleshipKind = &gameKind{
+	name:       "bs",
+	title:      "Battleship",
+	minPlayers: 2,
+	maxPlayers: 2,
+	againLabel: "Rematch",
+	peekLabel:  "My board",
+	newGame: func(players []string) Game {
+		return &battleship{
+			IDs:    [2]string{players[0], players[1]},
+			Boards: [2]*battleshipBoard{{}, {}},
+		}
+	},
+	decode: decodeGame[battleship],
+}
+
+const battleshipSize = 10
+
+var battleshipFleet = []struct {
+	Name string
+	Size int
+}{
+	{"Carrier", 5},
+	{"Battleship", 4},
+	{"Cruiser", 3},
+	{"Submarine", 3},
+	{"Destroyer", 2},
+}
+
+// battleshipBoard is one player's waters: their ships and the shots fired at them.
+type battleshipBoard struct {
+	// Ships holds the cells of each placed ship, in fleet order.
+	Ships [][]int
+	Shots [battleshipSize * battleshipSize]bool
+	// PlaceRow, PlaceCol and Vertical position the next ship while placing.
+	PlaceRow int
+	PlaceCol int
+	Vertical bool
+}
+
+func (b *battleshipBoard) placed() bool {
+	return len(b.Ships) == len(battleshipFleet)
+}
+
+func (b *battleshipBoard) shipAt(cell int) int {
+	for k, ship := range b.Ships {
+		if slices.Contains(ship, cell) {
+			return k
+		}
+	}
+	return -1
+}
+
+func (b *battleshipBoard) sunk(ship int) bool {
+	for _, cell := range b.Ships[ship] {
+		if !b.Shots[cell] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *battleshipBoard) allSunk() bool {
+	for k := range b.Ships {
+		if !b.sunk(k) {
+			return false
+		}
+	}
+	return b.placed()
+}
+
+// cells returns where a ship of the given size would sit, or nil if it runs
+// off the board or overlaps another ship.
+func (b *battleshipBoard) cells(row, col, size int, vertical bool) []int {
+	var cells []int
+	for k := range size {
+		r, c := row, col+k
+		if vertical {
+			r, c = row+k, col
+		}
+		if r >= battleshipSize || c >= battleshipSize || b.shipAt(r*battleshipSize+c) >= 0 {
+			return nil
+		}
+		cells = append(cells, r*battleshipSize+c)
+	}
+	return cells
+}
+
+// placeRandomly places the rest of the fleet anywhere it fits.
+func (b *battleshipBoard) placeRandomly() {
+	for !b.placed() {
+		size := battleshipFleet[len(b.Ships)].Size
+		cells := b.cells(rand.Intn(battleshipSize), rand.Intn(battleshipSize), size, rand.Intn(2) == 0)
+		if cells != nil {
+			b.Ships = append(b.Ships, cells)
+		}
+	}
+}
+
+// grid draws the board. Own boards show ships; the shared view shows only shots
+// until the game is over. preview marks where the next ship would go.
+func (b *battleshipBoard) grid(showShips bool, preview []int) string {
+	var sb strings.Builder
+	sb.WriteString("   1 2 3 4 5 6 7 8 9 10\n")
+	for r := range battleshipSize {
+		fmt.Fprintf(&sb, "%c ", 'A'+r)
+		for c := range battleshipSize {
+			cell := r*battleshipSize + c
+			ship := b.shipAt(cell)
+			mark := "·"
+			switch {
+			case slices.Contains(preview, cell):
+				mark = "+"
+			case b.Shots[cell] && ship >= 0:
+				mark = "X"
+			case b.Shots[cell]:
+				mark = "o"
+			case showShips && ship >= 0:
+				mark = "#"
+			}
+			sb.WriteString(" " + mark)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+type battleship struct {
+	IDs    [2]string
+	Boards [2]*battleshipBoard
+	// Turn is the index of the player firing next.
+	Turn   int
+	Winner string
+	// Aiming is set once the shooter has picked a row, AimRow.
+	Aiming bool
+	AimRow int
+	Last   string
+}
+
+func (g *battleship) players() []string { return g.IDs[:] }
+
+func (g *battleship) placing() bool {
+	return !g.Boards[0].placed() || !g.Boards[1].placed()
+}
+
+// turn lets both players place their ships at the same time.
+func (g *battleship) turn() string {
+	if g.placing() {
+		return ""
+	}
+	return g.IDs[g.Turn]
+}
+
+func (g *battleship) seat(playerID string) int {
+	if g.IDs[0] == playerID {
+		return 0
+	}
+	return 1
+}
+
+// actions is the coordinate picker for the shooter: a row menu, then a column menu.
+func (g *battleship) actions() []action {
+	if g.placing() {
+		return nil
+	}
+	target := g.Boards[1-g.Turn]
+	if !g.Aiming {
+		var rows []discordgo.SelectMenuOption
+		for r := range battleshipSize {
+			if slices.Contains(target.Shots[r*battleshipSize:(r+1)*battleshipSize], false) {
+				rows = append(rows, discordgo.SelectMenuOption{Label: fmt.Sprintf("Row %c", 'A'+r), Value: strconv.Itoa(r)})
+			}
+		}
+		return []action{{ID: "aim", Label: "Pick a row to fire at", Choices: rows}}
+	}
+	var cols []discordgo.SelectMenuOption
+	for c := range battleshipSize {
+		if !target.Shots[g.AimRow*battleshipSize+c] {
+			cols = append(cols, discordgo.SelectMenuOption{Label: fmt.Sprintf("%c%d", 'A'+g.AimRow, c+1), Value: strconv.Itoa(c)})
+		}
+	}
+	return []action{
+		{ID: "fire", Label: fmt.Sprintf("Fire at row %c", 'A'+g.AimRow), Choices: cols},
+		{ID: "back", Label: "Back", Style: discordgo.SecondaryButton},
+	}
+}
+
+func (g *battleship) apply(playerID, actionID string) error {
+	verb, arg, _ := strings.Cut(actionID, ":")
+	n, _ := strconv.Atoi(arg)
+	board := g.Boards[g.seat(playerID)]
+
+	switch verb {
+	case "row", "col", "rotate", "place", "random", "reset":
+		if !g.placing() {
+			return errors.New("The ships are already in position.")
+		}
+		if board.placed() && verb != "reset" {
+			return errors.New("Your fleet is placed. Waiting for your opponent.")
+		}
+	case "aim", "fire", "back":
+		if g.placing() {
+			return errors.New("Both fleets need to be placed first.")
+		}
+	}
+
+	switch verb {
+	case "row":
+		board.PlaceRow = min(max(n, 0), battleshipSize-1)
+	case "col":
+		board.PlaceCol = min(max(n, 0), battleshipSize-1)
+	case "rotate":
+		board.Vertical = !board.Vertical
+	case "place":
+		ship := battleshipFleet[len(board.Ships)]
+		cells := board.cells(board.PlaceRow, board.PlaceCol, ship.Size, board.Vertical)
+		if cells == nil {
+			return fmt.Errorf("Your %s doesn't fit there.", ship.Name)
+		}
+		board.Ships = append(board.Ships, cells)
+	case "random":
+		board.placeRandomly()
+	case "reset":
+		board.Ships = nil
+	case "aim":
+		g.Aiming, g.AimRow = true, min(max(n, 0), battleshipSize-1)
+	case "back":
+		g.Aiming = false
+	case "fire":
+		if !g.Aiming || n < 0 || n >= battleshipSize {
+			return errors.New("Pick a row first.")
+		}
+		target := g.Boards[1-g.Turn]
+		cell := g.AimRow*battleshipSize + n
+		if target.Shots[cell] {
+			return errors.New("You've already fired there.")
+		}
+		target.Shots[cell] = true
+		g.Aiming = false
+		coord := fmt.Sprintf("%c%d", 'A'+g.AimRow, n+1)
+		switch ship := target.shipAt(cell); {
+		case ship < 0:
+			g.Last = fmt.Sprintf("<@%s> fired at %s — miss.", playerID, coord)
+		case target.sunk(ship):
+			g.Last = fmt.Sprintf("<@%s> fired at %s — hit and sunk the %s!", playerID, coord, battleshipFleet[ship].Name)
+		default:
+			g.Last = fmt.Sprintf("<@%s> fired at %s — hit!", playerID, coord)
+		}
+		if target.allSunk() {
+			g.Winner = playerID
+			return nil
+		}
+		g.Turn = 1 - g.Turn
+	default:
+		return fmt.Errorf("unknown battleship action %q", actionID)
+	}
+	return nil
+}
+
+// render shows both players' waters with only the shots on them, so no ship is
+// given away until the game ends.
+func (g *battleship) render() string {
+	over, _ := g.outcome()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Battleship** <@%s> vs <@%s>\n", g.IDs[0], g.IDs[1])
+	for k, b := range g.Boards {
+		var sunk []string
+		for ship := range b.Ships {
+			if b.sunk(ship) {
+				sunk = append(sunk, battleshipFleet[ship].Name)
+			}
+		}
+		fmt.Fprintf(&sb, "<@%s>'s waters", g.IDs[k])
+		if len(sunk) > 0 {
+			fmt.Fprintf(&sb, " (sunk: %s)", strings.Join(sunk, ", "))
+		}
+		fmt.Fprintf(&sb, "\n```\n%s```\n", b.grid(over, nil))
+	}
+	switch {
+	case g.placing():
+		for k, b := range g.Boards {
+			if b.placed() {
+				fmt.Fprintf(&sb, "<@%s> is ready. ", g.IDs[k])
+			} else {
+				fmt.Fprintf(&sb, "<@%s> is placing ships. ", g.IDs[k])
+			}
+		}
+		sb.WriteString("\nPress **My board** to place your fleet.")
+	case over:
+		if g.Last != "" {
+			sb.WriteString(g.Last + "\n")
+		}
+		fmt.Fprintf(&sb, "🎉 <@%s> sank the whole fleet!", g.Winner)
+	default:
+		if g.Last != "" {
+			sb.WriteString(g.Last + "\n")
+		}
+		fmt.Fprintf(&sb, "<@%s> to fire.", g.IDs[g.Turn])
+	}
+	return sb.String()
+}
+
+// privateView shows a player their own ships and, while placing, the controls
+// for positioning the next one.
+func (g *battleship) privateView(playerID string) (string, []action) {
+	board := g.Boards[g.seat(playerID)]
+	var sb strings.Builder
+	if board.placed() {
+		fmt.Fprintf(&sb, "Your fleet\n```\n%s```", board.grid(true, nil))
+		if g.placing() {
+			sb.WriteString("Waiting for your opponent to place their ships.")
+			return sb.String(), []action{{ID: "reset", Label: "Start over", Style: discordgo.DangerButton}}
+		}
+		return sb.String(), nil
+	}
+
+	ship := battleshipFleet[len(board.Ships)]
+	preview := board.cells(board.PlaceRow, board.PlaceCol, ship.Size, board.Vertical)
+	fmt.Fprintf(&sb, "Place your **%s** (%d)\n```\n%s```", ship.Name, ship.Size, board.grid(true, preview))
+	direction := "across"
+	if board.Vertical {
+		direction = "down"
+	}
+	fmt.Fprintf(&sb, "From %c%d going %s", 'A'+board.PlaceRow, board.PlaceCol+1, direction)
+	if preview == nil {
+		sb.WriteString(" — it doesn't fit there.")
+	}
+
+	var rows, cols []discordgo.SelectMenuOption
+	for k := range battleshipSize {
+		rows = append(rows, discordgo.SelectMenuOption{Label: fmt.Sprintf("Row %c", 'A'+k), Value: strconv.Itoa(k), Default: k == board.PlaceRow})
+		cols = append(cols, discordgo.SelectMenuOption{Label: fmt.Sprintf("Column %d", k+1), Value: strconv.Itoa(k), Default: k == board.PlaceCol})
+	}
+	actions := []action{
+		{ID: "row", Label: "Row", Choices: rows},
+		{ID: "col", Label: "Column", Choices: cols},
+		{ID: "place", Label: "Place " + ship.Name, Style: discordgo.SuccessButton, Disabled: preview == nil},
+		{ID: "rotate", Label: "Rotate", Style: discordgo.PrimaryButton},
+		{ID: "random", Label: "Place the rest randomly", Style: discordgo.SecondaryButton},
+	}
+	if len(board.Ships) > 0 {
+		actions = append(actions, action{ID: "reset", Label: "Start over", Style: discordgo.DangerButton})
+	}
+	return sb.String(), actions
+}
+
+func (g *battleship) outcome() (bool, string) {
+	return g.Winner != "", g.Winner
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	bj "geofbot/blackjack"
+)
+
+const (
+	trainerFile = "trainer.json"
+	shoeDecks   = 6
+	// shoeCutCard is how many cards are left when the shoe is reshuffled.
+	shoeCutCard = 78
+)
+
+// blackjackTrainerKind deals hands for fun from the player's own shoe and
+// checks each decision against basic strategy.
+var blackjackTrainerKind = &gameKind{
+	name:       "bjt",
+	title:      "Blackjack trainer",
+	minPlayers: 1,
+	maxPlayers: 1,
+	againLabel: "Next hand",
+	newGame: func(players []string) Game {
+		return dealTrainer(players[0])
+	},
+	decode: decodeGame[blackjack],
+}
+
+// trainerStats is a player's record in trainer mode. The shoe carries over
+// between hands so the running count means something.
+type trainerStats struct {
+	Decisions int
+	Correct   int
+	ShowCount bool
+	Shoe      bj.Deck
+	// Count is the Hi-Lo running count of the cards the player has seen dealt
+	// from Shoe.
+	Count int
+}
+
+var (
+	// trainers maps user ID to trainer stats.
+	trainers   map[string]*trainerStats
+	trainersMu sync.Mutex
+)
+
+func loadTrainers() {
+	trainersMu.Lock()
+	defer trainersMu.Unlock()
+	trainers = make(map[string]*trainerStats)
+	if err := loadJSON(trainerFile, &trainers); err != nil {
+		fmt.Println("loadTrainers error:", err)
+	}
+}
+
+// userTrainer returns a player's stats, creating them on first use. Callers must hold trainersMu.
+func userTrainer(userID string) *trainerStats {
+	t, ok := trainers[userID]
+	if !ok {
+		t = &trainerStats{}
+		trainers[userID] = t
+	}
+	return t
+}
+
+func saveTrainers() {
+	if err := saveJSON(trainerFile, trainers); err != nil {
+		fmt.Println("trainer save error:", err)
+	}
+}
+
+func setTrainerCount(userID string, show bool) {
+	trainersMu.Lock()
+	defer trainersMu.Unlock()
+	userTrainer(userID).ShowCount = show
+	saveTrainers()
+}
+
+// dealTrainer deals a hand from the player's shoe, reshuffling it once it
+// reaches the cut card. The dealt cards leave the shoe straight away, so a
+// hand that's abandoned doesn't deal them again, and the ones the player can
+// see are counted.
+func dealTrainer(playerID string) *blackjack {
+	trainersMu.Lock()
+	defer trainersMu.Unlock()
+	t := userTrainer(playerID)
+	if len(t.Shoe) < shoeCutCard {
+		t.Shoe = bj.NewShoe(shoeDecks)
+		t.Count = 0
+	}
+	g := dealBlackjack(playerID, t.Shoe)
+	g.Trainer = true
+	t.Shoe = g.Deck
+	t.Count += hiLo(g.PlayerCards) + hiLo(g.DealerCards[1:])
+	saveTrainers()
+	return g
+}
+
+// returnShoe puts what's left of the shoe back once a hand is over and counts
+// the cards dealt since the opening hands, and the hole card.
+func (g *blackjack) returnShoe() {
+	trainersMu.Lock()
+	defer trainersMu.Unlock()
+	t := userTrainer(g.PlayerID)
+	t.Shoe = g.Deck
+	t.Count += g.countSinceDeal() + hiLo(g.DealerCards[:1])
+	saveTrainers()
+}
+
+// countSinceDeal is the Hi-Lo count of the cards dealt after the opening hands.
+func (g *blackjack) countSinceDeal() int {
+	return hiLo(g.PlayerCards[2:]) + hiLo(g.DealerCards[2:])
+}
+
+// hiLo counts low cards +1 and tens and aces -1.
+func hiLo(cards []string) int {
+	count := 0
+	for _, c := range cards {
+		switch v := bj.CardValues[c]; {
+		case v <= 6:
+			count++
+		case v >= 10:
+			count--
+		}
+	}
+	return count
+}
+
+// coach grades a decision before it's applied and records it in the player's accuracy.
+func (g *blackjack) coach(actionID string) {
+	best := bj.BasicStrategy(g.PlayerCards, g.DealerCards[1])
+	names := map[string]string{"hit": "Hit", "stay": "Stay"}
+	soft := "hard"
+	if bj.IsSoft(g.PlayerCards) {
+		soft = "soft"
+	}
+	situation := fmt.Sprintf("%s %d vs %s", soft, bj.HandScore(g.PlayerCards), g.DealerCards[1])
+	correct := actionID == best
+	if correct {
+		g.Feedback = fmt.Sprintf("✅ %s on %s matches basic strategy.", names[actionID], situation)
+	} else {
+		g.Feedback = fmt.Sprintf("❌ Basic strategy says %s on %s.", names[best], situation)
+	}
+
+	trainersMu.Lock()
+	defer trainersMu.Unlock()
+	t := userTrainer(g.PlayerID)
+	t.Decisions++
+	if correct {
+		t.Correct++
+	}
+	saveTrainers()
+}
+
+// trainerNotes shows the last decision's grade, the player's accuracy and,
+// if they asked for it, the count.
+func (g *blackjack) trainerNotes() string {
+	var sb strings.Builder
+	if g.Feedback != "" {
+		sb.WriteString("\r\n" + g.Feedback)
+	}
+	trainersMu.Lock()
+	defer trainersMu.Unlock()
+	t := userTrainer(g.PlayerID)
+	if t.Decisions > 0 {
+		fmt.Fprintf(&sb, "\r\nAccuracy: %d/%d (%.0f%%)", t.Correct, t.Decisions, 100*float64(t.Correct)/float64(t.Decisions))
+	}
+	if !t.ShowCount {
+		return sb.String()
+	}
+	// Only the cards the player has seen count: the hole card stays hidden until
+	// the hand is over, and by then returnShoe has counted the whole hand.
+	count := t.Count
+	if over, _ := g.outcome(); !over {
+		count += g.countSinceDeal()
+	}
+	decksLeft := float64(len(g.Deck)) / 52
+	fmt.Fprintf(&sb, "\r\nRunning count: %+d · True count: %+.1f (%.1f decks left)", count, float64(count)/decksLeft, decksLeft)
+	return sb.String()
+}
@@ -2,193 +2,189 @@ package main
 
 import (
 	"fmt"
-	"math/rand"
-	"slices"
 
+	bj "geofbot/blackjack"
 	"github.com/bwmarrin/discordgo"
 )
 
+var blackjackKind = &gameKind{
+	name:       "bj",
+	title:      "Blackjack",
+	minPlayers: 1,
+	maxPlayers: 1,
+	againLabel: "Reset",
+	newGame: func(players []string) Game {
+		g := newBlackjack(players[0])
+		g.stake()
+		return g
+	},
+	decode: decodeGame[blackjack],
+}
+
 type blackjack struct {
-	ID          string
-	PlayerID    string
-	Deck        deck
-	DealerCards []string
-	PlayerCards []string
-	Result      string
+	ID       string
+	PlayerID string
+	bj.Hand
+	PlayerScore int
+	DealerScore int
+	// Bet is the stake taken from the player's wallet for this hand.
+	Bet int
+	// BetNote explains why a hand was dealt without the player's usual bet.
+	BetNote string
+	// Trainer hands are dealt from the player's shoe and coached on every decision.
+	Trainer  bool
+	Feedback string
 }
 
-func (g *blackjack) hit() (int, int) {
-	// zone := tracy.Zone("game.hit")
-	// defer zone.End()
-	playerCard, newDeck := g.Deck.deal()
-	g.PlayerCards = append(g.PlayerCards, playerCard)
-	g.Deck = newDeck
-	return g.react(true)
+// newBlackjack shuffles a fresh deck and deals the opening hands.
+func newBlackjack(playerID string) *blackjack {
+	d := bj.NewDeck()
+	d.Shuffle()
+	return dealBlackjack(playerID, d)
 }
 
-func (g *blackjack) stay() (int, int) {
-	// zone := tracy.Zone("game.stay")
-	// defer zone.End()
-	return g.react(false)
+// dealBlackjack deals the opening hands from the top of d.
+func dealBlackjack(playerID string, d bj.Deck) *blackjack {
+	g := &blackjack{PlayerID: playerID, Hand: *bj.Deal(d)}
+	g.PlayerScore = bj.HandScore(g.PlayerCards)
+	return g
 }
 
-func (g *blackjack) react(playerHit bool) (playerScore int, dealerScore int) {
-	// zone := tracy.Zone("game.react")
-	// defer zone.End()
-	defer func() {
-		fmt.Println(playerScore, dealerScore)
-		fmt.Println(g.PlayerCards, g.DealerCards)
-	}()
-	dealerAce, playerAce := 0, 0
-	for i := range g.DealerCards {
-		numDealer := cardValues[g.DealerCards[i]]
-		dealerScore += numDealer
-		if g.DealerCards[i] == "A" {
-			dealerAce++
-		}
-	}
-	for i := range g.PlayerCards {
-		numPlayer := cardValues[g.PlayerCards[i]]
-		playerScore += numPlayer
-		if g.PlayerCards[i] == "A" {
-			playerAce++
-		}
-	}
-	for dealerScore > 21 && dealerAce > 0 {
-		dealerAce--
-		dealerScore -= 10
-	}
-	for playerScore > 21 && playerAce > 0 {
-		playerAce--
-		playerScore -= 10
-	}
-	if playerScore > 21 {
-		g.Result = "DealerWin"
-		return
-	}
-	if dealerScore > 21 {
-		g.Result = "PlayerWin"
-		return
-	}
-	dealerHit := false
-	if dealerScore < 17 || (dealerScore == 17 && slices.Contains(g.DealerCards, "A")) {
-		dealerCard, newDeck := g.Deck.deal()
-		if dealerCard == "A" {
-			dealerAce++
-		}
-		g.DealerCards = append(g.DealerCards, dealerCard)
-		g.Deck = newDeck
-		dealerHit = true
-		dealerScore = dealerScore + cardValues[g.DealerCards[len(g.DealerCards)-1]]
-		for dealerScore > 21 && dealerAce > 0 {
-			dealerAce--
-			dealerScore -= 10
-		}
+// stake takes the player's standing blackjack bet from their wallet, dealing
+// the hand for fun if they can't cover it.
+func (g *blackjack) stake() {
+	walletsMu.Lock()
+	defer walletsMu.Unlock()
+	w := userWallet(g.PlayerID)
+	switch {
+	case w.BlackjackBet == 0:
+	case w.Chips < w.BlackjackBet:
+		g.BetNote = fmt.Sprintf("You can't cover your %d chip bet, so this hand is for fun.", w.BlackjackBet)
+	default:
+		w.Chips -= w.BlackjackBet
+		g.Bet = w.BlackjackBet
+		saveWallets()
 	}
+}
 
-	if dealerScore > 21 {
-		g.Result = "PlayerWin"
-		return
-	}
-	if !playerHit && !dealerHit {
-		if playerScore > dealerScore {
-			g.Result = "PlayerWin"
-		} else if dealerScore > playerScore {
-			g.Result = "DealerWin"
-		} else {
-			g.Result = "Tie"
-		}
-		return
+// settle pays out a finished hand. Ties pay like wins, as the message for them says.
+func (g *blackjack) settle() {
+	if over, winner := g.outcome(); over && winner != "" && g.Bet > 0 {
+		walletCredit(g.PlayerID, 2*g.Bet)
 	}
-	g.Result = "Playing"
-	return
 }
 
-var blackjackGames = make(map[string]*blackjack)
-
-var cardValues = map[string]int{
-	"2":  2,
-	"3":  3,
-	"4":  4,
-	"5":  5,
-	"6":  6,
-	"7":  7,
-	"8":  8,
-	"9":  9,
-	"10": 10,
-	"J":  10,
-	"Q":  10,
-	"K":  10,
-	"A":  11,
-}
+func (g *blackjack) players() []string { return []string{g.PlayerID} }
 
-type deck []string
+func (g *blackjack) turn() string { return g.PlayerID }
 
-func (d deck) deal() (string, deck) {
-	if len(d) == 0 {
-		return "", d
+func (g *blackjack) actions() []action {
+	return []action{
+		{ID: "hit", Label: "Hit", Style: discordgo.DangerButton},
+		{ID: "stay", Label: "Stay", Style: discordgo.DangerButton},
 	}
-	card := d[0]
-	d = d[1:]
-	return card, d
 }
 
-func newDeck() deck {
-	return []string{
-		"2", "2", "2", "2",
-		"3", "3", "3", "3",
-		"4", "4", "4", "4",
-		"5", "5", "5", "5",
-		"6", "6", "6", "6",
-		"7", "7", "7", "7",
-		"8", "8", "8", "8",
-		"9", "9", "9", "9",
-		"10", "10", "10", "10",
-		"J", "J", "J", "J",
-		"Q", "Q", "Q", "Q",
-		"K", "K", "K", "K",
-		"A", "A", "A", "A",
+func (g *blackjack) apply(playerID, actionID string) error {
+	if g.Trainer {
+		g.coach(actionID)
+	}
+	switch actionID {
+	case "hit":
+		g.PlayerScore, g.DealerScore = g.Hit()
+	case "stay":
+		g.PlayerScore, g.DealerScore = g.Stay()
+	default:
+		return fmt.Errorf("unknown blackjack action %q", actionID)
 	}
+	g.settle()
+	if over, _ := g.outcome(); over && g.Trainer {
+		g.returnShoe()
+	}
+	return nil
 }
 
-func (d deck) shuffle() {
-	// Implement a simple shuffle algorithm, e.g., Fisher-Yates
-	for i := len(d) - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
-		d[i], d[j] = d[j], d[i]
+func (g *blackjack) render() string {
+	content := buildBlackJackContent(g, g.PlayerScore, g.DealerScore)
+	if g.Trainer {
+		content += g.trainerNotes()
+	}
+	if g.BetNote != "" {
+		content += "\r\n" + g.BetNote
 	}
+	if g.Bet == 0 {
+		return content
+	}
+	switch over, winner := g.outcome(); {
+	case !over:
+		content += fmt.Sprintf("\r\nBet: %d chips", g.Bet)
+	case winner != "":
+		content += fmt.Sprintf("\r\n💰 You win %d chips! Balance: %d", g.Bet, walletBalance(g.PlayerID))
+	default:
+		content += fmt.Sprintf("\r\nYou lose %d chips. Balance: %d", g.Bet, walletBalance(g.PlayerID))
+	}
+	return content
 }
 
-// gameComponents returns the correct button row based on the current game result.
-// "Playing" → Hit + Stay buttons; any terminal result → Reset button only.
-func gameComponents(result string) []discordgo.MessageComponent {
-	if result == "Playing" {
-		return []discordgo.MessageComponent{
-			discordgo.ActionsRow{
-				Components: []discordgo.MessageComponent{
-					discordgo.Button{
-						Style:    discordgo.DangerButton,
-						Label:    "Hit",
-						CustomID: "hit-btn",
-					},
-					discordgo.Button{
-						Style:    discordgo.DangerButton,
-						Label:    "Stay",
-						CustomID: "stay-btn",
-					},
-				},
-			},
+// handleBlackjack updates the player's standing bet and count display if they
+// gave them and deals a hand in the chosen mode.
+func handleBlackjack(s *discordgo.Session, i *discordgo.InteractionCreate, om optionMap) {
+	if opt, ok := om["bet"]; ok {
+		bet := int(opt.IntValue())
+		if bet < 0 || bet > maxWager {
+			respondEphemeral(s, i, fmt.Sprintf("Bets must be between 0 and %d chips.", maxWager))
+			return
 		}
+		walletsMu.Lock()
+		userWallet(interactionUserID(i)).BlackjackBet = bet
+		saveWallets()
+		walletsMu.Unlock()
+	}
+	kind := blackjackKind.name
+	if opt, ok := om["mode"]; ok {
+		kind = opt.StringValue()
 	}
-	return []discordgo.MessageComponent{
-		discordgo.ActionsRow{
-			Components: []discordgo.MessageComponent{
-				discordgo.Button{
-					Style:    discordgo.PrimaryButton,
-					Label:    "Reset",
-					CustomID: "reset-btn",
-				},
-			},
-		},
+	if opt, ok := om["count"]; ok {
+		setTrainerCount(interactionUserID(i), opt.BoolValue())
+	}
+	host.open(s, i, kind)
+}
+
+// outcome counts a tie as a player win, matching the message shown for it.
+func (g *blackjack) outcome() (bool, string) {
+	switch g.Result {
+	case bj.Playing:
+		return false, ""
+	case bj.DealerWin:
+		return true, ""
+	default:
+		return true, g.PlayerID
+	}
+}
+
+// buildBlackJackContent formats the message content string based on the current game state.
+func buildBlackJackContent(g *blackjack, playerScore, dealerScore int) string {
+	switch g.Result {
+	case bj.DealerWin:
+		return fmt.Sprintf(
+			"Dealer Cards: **%v**\r\nPlayer Cards: **%v** = **%d**\r\nDealer won with a score of %d",
+			g.DealerCards, g.PlayerCards, playerScore, dealerScore,
+		)
+	case bj.PlayerWin:
+		return fmt.Sprintf(
+			"Dealer Cards: **%v**\r\nPlayer Cards: **%v** = **%d**\r\nPlayer won with a score of %d",
+			g.DealerCards, g.PlayerCards, playerScore, playerScore,
+		)
+	case bj.Tie:
+		return fmt.Sprintf(
+			"Dealer Cards: **%v**\r\nPlayer Cards: **%v** = **%d**\r\nScores are tied at %d, so Player wins",
+			g.DealerCards, g.PlayerCards, playerScore, playerScore,
+		)
+
+	default: // "Playing"
+		return fmt.Sprintf(
+			"Dealer Cards: ? + **%v**\r\nPlayer Cards: **%v** = **%d**",
+			g.DealerCards[1:], g.PlayerCards, playerScore,
+		)
 	}
 }
@@ -0,0 +1,257 @@
+// Package blackjack holds the rules of the bot's blackjack table, so the bot
+// and cmd/bjsim play exactly the same game.
+package blackjack
+
+import (
+	"math/rand"
+	"slices"
+)
+
+// Results a hand can be in. A Tie goes to the player at the bot's table.
+const (
+	Playing   = "Playing"
+	PlayerWin = "PlayerWin"
+	DealerWin = "DealerWin"
+	Tie       = "Tie"
+)
+
+// Rules are the table rules that change how a hand plays out. The zero value
+// is the bot's table.
+type Rules struct {
+	// StandSoft17 makes the dealer stand on soft 17 instead of hitting it.
+	StandSoft17 bool
+}
+
+// Hand is one hand of blackjack between a player and the dealer. The dealer's
+// first card is the hole card; DealerCards[1] is the upcard.
+type Hand struct {
+	Deck        Deck
+	DealerCards []string
+	PlayerCards []string
+	Result      string
+	Rules       Rules
+}
+
+// Deal deals the opening hands from the top of d.
+func Deal(d Deck) *Hand {
+	dealerCard1, d := d.Deal()
+	playerCard1, d := d.Deal()
+	dealerCard2, d := d.Deal()
+	playerCard2, d := d.Deal()
+	return &Hand{
+		Deck:        d,
+		DealerCards: []string{dealerCard1, dealerCard2},
+		PlayerCards: []string{playerCard1, playerCard2},
+		Result:      Playing,
+	}
+}
+
+func (h *Hand) Hit() (int, int) {
+	// zone := tracy.Zone("game.hit")
+	// defer zone.End()
+	playerCard, newDeck := h.Deck.Deal()
+	h.PlayerCards = append(h.PlayerCards, playerCard)
+	h.Deck = newDeck
+	return h.React(true)
+}
+
+func (h *Hand) Stay() (int, int) {
+	// zone := tracy.Zone("game.stay")
+	// defer zone.End()
+	return h.React(false)
+}
+
+// React scores the hand after the player's move and lets the dealer draw,
+// setting Result.
+func (h *Hand) React(playerHit bool) (playerScore int, dealerScore int) {
+	// zone := tracy.Zone("game.react")
+	// defer zone.End()
+	dealerAce, playerAce := 0, 0
+	for i := range h.DealerCards {
+		numDealer := CardValues[h.DealerCards[i]]
+		dealerScore += numDealer
+		if h.DealerCards[i] == "A" {
+			dealerAce++
+		}
+	}
+	for i := range h.PlayerCards {
+		numPlayer := CardValues[h.PlayerCards[i]]
+		playerScore += numPlayer
+		if h.PlayerCards[i] == "A" {
+			playerAce++
+		}
+	}
+	for dealerScore > 21 && dealerAce > 0 {
+		dealerAce--
+		dealerScore -= 10
+	}
+	for playerScore > 21 && playerAce > 0 {
+		playerAce--
+		playerScore -= 10
+	}
+	if playerScore > 21 {
+		h.Result = DealerWin
+		return
+	}
+	if dealerScore > 21 {
+		h.Result = PlayerWin
+		return
+	}
+	dealerHit := false
+	soft17 := dealerScore == 17 && slices.Contains(h.DealerCards, "A") && !h.Rules.StandSoft17
+	if dealerScore < 17 || soft17 {
+		dealerCard, newDeck := h.Deck.Deal()
+		if dealerCard == "A" {
+			dealerAce++
+		}
+		h.DealerCards = append(h.DealerCards, dealerCard)
+		h.Deck = newDeck
+		dealerHit = true
+		dealerScore = dealerScore + CardValues[h.DealerCards[len(h.DealerCards)-1]]
+		for dealerScore > 21 && dealerAce > 0 {
+			dealerAce--
+			dealerScore -= 10
+		}
+	}
+
+	if dealerScore > 21 {
+		h.Result = PlayerWin
+		return
+	}
+	if !playerHit && !dealerHit {
+		if playerScore > dealerScore {
+			h.Result = PlayerWin
+		} else if dealerScore > playerScore {
+			h.Result = DealerWin
+		} else {
+			h.Result = Tie
+		}
+		return
+	}
+	h.Result = Playing
+	return
+}
+
+var CardValues = map[string]int{
+	"2":  2,
+	"3":  3,
+	"4":  4,
+	"5":  5,
+	"6":  6,
+	"7":  7,
+	"8":  8,
+	"9":  9,
+	"10": 10,
+	"J":  10,
+	"Q":  10,
+	"K":  10,
+	"A":  11,
+}
+
+// HandScore totals a hand, counting aces as 1 where 11 would bust.
+func HandScore(cards []string) int {
+	score, aces := 0, 0
+	for _, c := range cards {
+		score += CardValues[c]
+		if c == "A" {
+			aces++
+		}
+	}
+	for score > 21 && aces > 0 {
+		aces--
+		score -= 10
+	}
+	return score
+}
+
+// IsSoft reports whether a hand counts an ace as 11.
+func IsSoft(cards []string) bool {
+	total, aces := 0, 0
+	for _, c := range cards {
+		total += CardValues[c]
+		if c == "A" {
+			aces++
+		}
+	}
+	for total > 21 && aces > 0 {
+		aces--
+		total -= 10
+	}
+	return aces > 0
+}
+
+// BasicStrategy returns "hit" or "stay" for a hand against the dealer's
+// upcard, for a game without doubling or splitting. The hit/stand chart is the
+// same whether the dealer hits or stands on soft 17.
+func BasicStrategy(cards []string, upcard string) string {
+	total, soft := HandScore(cards), IsSoft(cards)
+	up := CardValues[upcard]
+	switch {
+	case soft && total >= 19, !soft && total >= 17:
+		return "stay"
+	case soft && total == 18:
+		if up <= 8 {
+			return "stay"
+		}
+		return "hit"
+	case soft, total <= 11:
+		return "hit"
+	case total == 12:
+		if up >= 4 && up <= 6 {
+			return "stay"
+		}
+		return "hit"
+	default: // hard 13-16
+		if up <= 6 {
+			return "stay"
+		}
+		return "hit"
+	}
+}
+
+type Deck []string
+
+func (d Deck) Deal() (string, Deck) {
+	if len(d) == 0 {
+		return "", d
+	}
+	card := d[0]
+	d = d[1:]
+	return card, d
+}
+
+func NewDeck() Deck {
+	return []string{
+		"2", "2", "2", "2",
+		"3", "3", "3", "3",
+		"4", "4", "4", "4",
+		"5", "5", "5", "5",
+		"6", "6", "6", "6",
+		"7", "7", "7", "7",
+		"8", "8", "8", "8",
+		"9", "9", "9", "9",
+		"10", "10", "10", "10",
+		"J", "J", "J", "J",
+		"Q", "Q", "Q", "Q",
+		"K", "K", "K", "K",
+		"A", "A", "A", "A",
+	}
+}
+
+// NewShoe returns n decks shuffled together.
+func NewShoe(n int) Deck {
+	var d Deck
+	for range n {
+		d = append(d, NewDeck()...)
+	}
+	d.Shuffle()
+	return d
+}
+
+func (d Deck) Shuffle() {
+	// Implement a simple shuffle algorithm, e.g., Fisher-Yates
+	for i := len(d) - 1; i > 0; i-- {
+		j := rand.Intn(i + 1)
+		d[i], d[j] = d[j], d[i]
+	}
+}
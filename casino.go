@@ -0,0 +1,344 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const slotsFile = "slots.json"
+
+// slotSymbol is one symbol on the reels. Weight is how often it comes up
+// relative to the others; PaysAll and PaysTwo are what it pays on every reel,
+// or on just the first two, as a multiple of the bet (including the stake).
+type slotSymbol struct {
+	Emoji   string
+	Weight  int
+	PaysAll int
+	PaysTwo int
+}
+
+// slotsConfig is the reel weights and paytable. It can be overridden by
+// putting a slots.json in the data directory.
+type slotsConfig struct {
+	Reels   int
+	Symbols []slotSymbol
+}
+
+var defaultSlots = slotsConfig{
+	Reels: 3,
+	Symbols: []slotSymbol{
+		{Emoji: "🍒", Weight: 30, PaysAll: 10, PaysTwo: 2},
+		{Emoji: "🍋", Weight: 25, PaysAll: 15},
+		{Emoji: "🍊", Weight: 20, PaysAll: 20},
+		{Emoji: "🍇", Weight: 12, PaysAll: 40},
+		{Emoji: "🔔", Weight: 8, PaysAll: 80},
+		{Emoji: "💎", Weight: 4, PaysAll: 200},
+		{Emoji: "7️⃣", Weight: 1, PaysAll: 1000},
+	},
+}
+
+var slots = defaultSlots
+
+func loadSlots() {
+	cfg := defaultSlots
+	if err := loadJSON(slotsFile, &cfg); err != nil {
+		fmt.Println("loadSlots error:", err)
+		return
+	}
+	if cfg.Reels < 2 || len(cfg.Symbols) == 0 {
+		fmt.Println("loadSlots error: slots.json needs at least 2 reels and one symbol")
+		return
+	}
+	for _, sym := range cfg.Symbols {
+		if sym.Weight <= 0 {
+			fmt.Printf("loadSlots error: %s needs a positive weight\n", sym.Emoji)
+			return
+		}
+	}
+	slots = cfg
+}
+
+func (c slotsConfig) spin() []int {
+	total := 0
+	for _, sym := range c.Symbols {
+		total += sym.Weight
+	}
+	reels := make([]int, c.Reels)
+	for k := range reels {
+		n := rand.Intn(total)
+		for s, sym := range c.Symbols {
+			if n < sym.Weight {
+				reels[k] = s
+				break
+			}
+			n -= sym.Weight
+		}
+	}
+	return reels
+}
+
+// payout returns the multiple of the bet a spin pays.
+func (c slotsConfig) payout(reels []int) int {
+	sym := c.Symbols[reels[0]]
+	same := 1
+	for same < len(reels) && reels[same] == reels[0] {
+		same++
+	}
+	switch {
+	case same == len(reels):
+		return sym.PaysAll
+	case same >= 2:
+		return sym.PaysTwo
+	}
+	return 0
+}
+
+// wager reads the bet option, defaulting to 10 chips.
+func wager(om optionMap) (int, error) {
+	bet := 10
+	if opt, ok := om["bet"]; ok {
+		bet = int(opt.IntValue())
+	}
+	if bet < 1 || bet > maxWager {
+		return 0, fmt.Errorf("Bets must be between 1 and %d chips.", maxWager)
+	}
+	return bet, nil
+}
+
+func respondPublic(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: content},
+	})
+	if err != nil {
+		fmt.Println("respondPublic error:", err)
+	}
+}
+
+func handleSlots(s *discordgo.Session, i *discordgo.InteractionCreate, om optionMap) {
+	userID := interactionUserID(i)
+	bet, err := wager(om)
+	if err == nil {
+		err = walletDebit(userID, bet)
+	}
+	if err != nil {
+		respondEphemeral(s, i, err.Error())
+		return
+	}
+	reels := slots.spin()
+	emoji := make([]string, len(reels))
+	for k, r := range reels {
+		emoji[k] = slots.Symbols[r].Emoji
+	}
+	content := fmt.Sprintf("🎰 <@%s> bets %d\n**[ %s ]**\n", userID, bet, strings.Join(emoji, " | "))
+	if win := bet * slots.payout(reels); win > 0 {
+		walletCredit(userID, win)
+		content += fmt.Sprintf("💰 Wins %d chips!", win)
+	} else {
+		content += "No luck this time."
+	}
+	content += fmt.Sprintf(" Balance: %d", walletBalance(userID))
+	respondPublic(s, i, content)
+}
+
+var rouletteReds = []int{1, 3, 5, 7, 9, 12, 14, 16, 18, 19, 21, 23, 25, 27, 30, 32, 34, 36}
+
+// rouletteBet parses a bet on the European wheel and returns which pockets win
+// and what a win pays as a multiple of the bet (including the stake).
+func rouletteBet(on string) (func(int) bool, int, error) {
+	on = strings.ToLower(strings.TrimSpace(on))
+	if n, err := strconv.Atoi(on); err == nil {
+		if n < 0 || n > 36 {
+			return nil, 0, errors.New("Pick a number from 0 to 36.")
+		}
+		return func(p int) bool { return p == n }, 36, nil
+	}
+	switch on {
+	case "red":
+		return func(p int) bool { return slices.Contains(rouletteReds, p) }, 2, nil
+	case "black":
+		return func(p int) bool { return p != 0 && !slices.Contains(rouletteReds, p) }, 2, nil
+	case "odd":
+		return func(p int) bool { return p%2 == 1 }, 2, nil
+	case "even":
+		return func(p int) bool { return p != 0 && p%2 == 0 }, 2, nil
+	case "low", "1-18":
+		return func(p int) bool { return p >= 1 && p <= 18 }, 2, nil
+	case "high", "19-36":
+		return func(p int) bool { return p >= 19 }, 2, nil
+	case "dozen1", "dozen2", "dozen3":
+		d := int(on[5] - '0')
+		return func(p int) bool { return p != 0 && (p-1)/12+1 == d }, 3, nil
+	case "column1", "column2", "column3":
+		c := int(on[6] - '0')
+		return func(p int) bool { return p != 0 && (p-1)%3+1 == c }, 3, nil
+	}
+	return nil, 0, errors.New("Bet on a number (0-36), red, black, odd, even, low, high, dozen1-3 or column1-3.")
+}
+
+func rouletteColor(p int) string {
+	switch {
+	case p == 0:
+		return "🟢"
+	case slices.Contains(rouletteReds, p):
+		return "🔴"
+	}
+	return "⚫"
+}
+
+func handleRoulette(s *discordgo.Session, i *discordgo.InteractionCreate, om optionMap) {
+	userID := interactionUserID(i)
+	on := om["on"].StringValue()
+	wins, pays, err := rouletteBet(on)
+	var bet int
+	if err == nil {
+		bet, err = wager(om)
+	}
+	if err == nil {
+		err = walletDebit(userID, bet)
+	}
+	if err != nil {
+		respondEphemeral(s, i, err.Error())
+		return
+	}
+	pocket := rand.Intn(37)
+	content := fmt.Sprintf("🎡 <@%s> bets %d on **%s**\nThe ball lands on %s **%d**\n", userID, bet, on, rouletteColor(pocket), pocket)
+	if wins(pocket) {
+		walletCredit(userID, bet*pays)
+		content += fmt.Sprintf("💰 Wins %d chips!", bet*pays)
+	} else {
+		content += "No luck this time."
+	}
+	content += fmt.Sprintf(" Balance: %d", walletBalance(userID))
+	respondPublic(s, i, content)
+}
+
+// coinflip is a head-to-head wager waiting to be accepted. Open challenges
+// aren't persisted, since no chips move until one is accepted.
+type coinflip struct {
+	ChallengerID string
+	// OpponentID is empty when anyone may accept.
+	OpponentID string
+	Bet        int
+}
+
+var (
+	coinflips   = make(map[string]*coinflip)
+	coinflipsMu sync.Mutex
+)
+
+func handleCoinflip(s *discordgo.Session, i *discordgo.InteractionCreate, om optionMap) {
+	userID := interactionUserID(i)
+	bet, err := wager(om)
+	if err == nil && walletBalance(userID) < bet {
+		err = fmt.Errorf("You only have %d chips.", walletBalance(userID))
+	}
+	cf := &coinflip{ChallengerID: userID, Bet: bet}
+	if opt, ok := om["opponent"]; ok && err == nil {
+		opponent := opt.UserValue(s)
+		switch {
+		case opponent.ID == userID:
+			err = errors.New("You can't flip against yourself!")
+		case opponent.Bot:
+			err = errors.New("Bots don't gamble.")
+		}
+		cf.OpponentID = opponent.ID
+	}
+	if err != nil {
+		respondEphemeral(s, i, err.Error())
+		return
+	}
+
+	id := newGameID()
+	coinflipsMu.Lock()
+	coinflips[id] = cf
+	coinflipsMu.Unlock()
+	who := "Anyone"
+	if cf.OpponentID != "" {
+		who = fmt.Sprintf("<@%s>", cf.OpponentID)
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("🪙 <@%s> wants to flip a coin for %d chips. %s can accept.", userID, bet, who),
+			Components: buttonRows([]discordgo.MessageComponent{
+				discordgo.Button{Style: discordgo.SuccessButton, Label: "Accept", CustomID: "coinflip-accept-" + id},
+				discordgo.Button{Style: discordgo.SecondaryButton, Label: "Decline", CustomID: "coinflip-decline-" + id},
+			}),
+		},
+	})
+	if err != nil {
+		fmt.Println("handleCoinflip respond error:", err)
+	}
+}
+
+// handleCoinflipButton settles or withdraws a coinflip challenge.
+func handleCoinflipButton(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
+	parts := strings.Split(customID, "-")
+	if len(parts) != 3 {
+		return
+	}
+	verb, id := parts[1], parts[2]
+	userID := interactionUserID(i)
+
+	coinflipsMu.Lock()
+	cf := coinflips[id]
+	var content string
+	var problem error
+	switch {
+	case cf == nil:
+		problem = errors.New("This coinflip is over.")
+	case verb == "decline":
+		if userID != cf.ChallengerID && userID != cf.OpponentID {
+			problem = errors.New("This isn't your coinflip.")
+			break
+		}
+		delete(coinflips, id)
+		content = fmt.Sprintf("🪙 The %d chip coinflip from <@%s> was called off.", cf.Bet, cf.ChallengerID)
+	case userID == cf.ChallengerID:
+		problem = errors.New("You can't accept your own coinflip.")
+	case cf.OpponentID != "" && userID != cf.OpponentID:
+		problem = errors.New("This coinflip is for someone else.")
+	default:
+		if problem = walletDebit(userID, cf.Bet); problem != nil {
+			break
+		}
+		if err := walletDebit(cf.ChallengerID, cf.Bet); err != nil {
+			walletCredit(userID, cf.Bet)
+			delete(coinflips, id)
+			content = fmt.Sprintf("🪙 <@%s> can no longer cover the %d chip coinflip.", cf.ChallengerID, cf.Bet)
+			break
+		}
+		delete(coinflips, id)
+		winner, side := cf.ChallengerID, "Heads"
+		if rand.Intn(2) == 1 {
+			winner, side = userID, "Tails"
+		}
+		walletCredit(winner, 2*cf.Bet)
+		content = fmt.Sprintf("🪙 <@%s> vs <@%s> for %d chips: **%s!** <@%s> wins %d chips.",
+			cf.ChallengerID, userID, cf.Bet, side, winner, 2*cf.Bet)
+	}
+	coinflipsMu.Unlock()
+
+	if problem != nil {
+		respondEphemeral(s, i, problem.Error())
+		return
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		fmt.Println("coinflip respond error:", err)
+	}
+}
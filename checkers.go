@@ -0,0 +1,300 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var checkersKind = &gameKind{
+	name:       "ck",
+	title:      "Checkers",
+	minPlayers: 2,
+	maxPlayers: 2,
+	againLabel: "Rematch",
+	newGame: func(players []string) Game {
+		g := &checkers{BlackID: players[0], WhiteID: players[1], BlackMove: true, Selected: -1}
+		for sq := range 64 {
+			if !checkersDark(sq) {
+				continue
+			}
+			switch r := sq / 8; {
+			case r <= 2:
+				g.Board[sq] = checkersMan
+			case r >= 5:
+				g.Board[sq] = -checkersMan
+			}
+		}
+		return g
+	},
+	decode: decodeGame[checkers],
+}
+
+// Black pieces are positive and move up the board; white pieces are negative.
+const (
+	checkersMan  int8 = 1
+	checkersKing int8 = 2
+)
+
+// checkersDrawPlies ends the game as a draw after 40 moves each without a
+// capture or a man moving.
+const checkersDrawPlies = 80
+
+func checkersDark(sq int) bool {
+	return (sq/8+sq%8)%2 == 0
+}
+
+// checkersMove is a full turn: a step, or a chain of jumps.
+type checkersMove struct {
+	Path     []int
+	Captures []int
+}
+
+func (m checkersMove) String() string {
+	sep := "-"
+	if len(m.Captures) > 0 {
+		sep = "x"
+	}
+	names := make([]string, len(m.Path))
+	for k, sq := range m.Path {
+		names[k] = squareName(sq)
+	}
+	return strings.Join(names, sep)
+}
+
+type checkersBoard [64]int8
+
+func (b *checkersBoard) dirs(piece int8) [][2]int {
+	switch {
+	case piece == checkersMan:
+		return [][2]int{{1, -1}, {1, 1}}
+	case piece == -checkersMan:
+		return [][2]int{{-1, -1}, {-1, 1}}
+	}
+	return [][2]int{{1, -1}, {1, 1}, {-1, -1}, {-1, 1}}
+}
+
+func checkersPromotes(piece int8, sq int) bool {
+	return (piece == checkersMan && sq/8 == 7) || (piece == -checkersMan && sq/8 == 0)
+}
+
+// jumps extends a capture chain from the last square of m as far as it will go.
+// A man that reaches the far row is crowned and the turn ends there.
+func (b *checkersBoard) jumps(m checkersMove, piece int8, out *[]checkersMove) {
+	from := m.Path[len(m.Path)-1]
+	extended := false
+	if !(len(m.Captures) > 0 && checkersPromotes(piece, from)) {
+		for _, d := range b.dirs(piece) {
+			r, f := from/8+d[0], from%8+d[1]
+			r2, f2 := r+d[0], f+d[1]
+			if !onBoard(f2, r2) {
+				continue
+			}
+			over, to := r*8+f, r2*8+f2
+			if b[over]*piece >= 0 || b[to] != 0 || slices.Contains(m.Captures, over) {
+				continue
+			}
+			next := checkersMove{
+				Path:     append(slices.Clone(m.Path), to),
+				Captures: append(slices.Clone(m.Captures), over),
+			}
+			b.jumps(next, piece, out)
+			extended = true
+		}
+	}
+	if !extended && len(m.Captures) > 0 {
+		*out = append(*out, m)
+	}
+}
+
+// legalMoves lists every move for a side. Captures are compulsory, and a
+// capturing piece must keep jumping while it can.
+func (b *checkersBoard) legalMoves(black bool) []checkersMove {
+	var captures, steps []checkersMove
+	for sq, piece := range b {
+		if piece == 0 || (piece > 0) != black {
+			continue
+		}
+		// Lift the piece so it doesn't block its own path while jumping around.
+		b[sq] = 0
+		b.jumps(checkersMove{Path: []int{sq}}, piece, &captures)
+		b[sq] = piece
+		for _, d := range b.dirs(piece) {
+			r, f := sq/8+d[0], sq%8+d[1]
+			if onBoard(f, r) && b[r*8+f] == 0 {
+				steps = append(steps, checkersMove{Path: []int{sq, r*8 + f}})
+			}
+		}
+	}
+	if len(captures) > 0 {
+		return captures
+	}
+	return steps
+}
+
+func (b *checkersBoard) play(m checkersMove) {
+	from, to := m.Path[0], m.Path[len(m.Path)-1]
+	piece := b[from]
+	b[from] = 0
+	for _, sq := range m.Captures {
+		b[sq] = 0
+	}
+	if checkersPromotes(piece, to) {
+		piece *= checkersKing
+	}
+	b[to] = piece
+}
+
+type checkers struct {
+	BlackID, WhiteID string
+	Board            checkersBoard
+	BlackMove        bool
+	// Selected is the square of the piece picked from the menu, or -1.
+	Selected int
+	// Quiet counts plies since the last capture or man move.
+	Quiet  int
+	Last   string
+	Winner string
+	Over   bool
+	Reason string
+}
+
+func (g *checkers) players() []string { return []string{g.BlackID, g.WhiteID} }
+
+func (g *checkers) turn() string {
+	if g.BlackMove {
+		return g.BlackID
+	}
+	return g.WhiteID
+}
+
+func (g *checkers) actions() []action {
+	legal := g.Board.legalMoves(g.BlackMove)
+	var actions []action
+	if g.Selected < 0 {
+		var from []discordgo.SelectMenuOption
+		for _, m := range legal {
+			name := squareName(m.Path[0])
+			if !slices.ContainsFunc(from, func(o discordgo.SelectMenuOption) bool { return o.Value == name }) {
+				from = append(from, discordgo.SelectMenuOption{Label: name, Value: name})
+			}
+		}
+		actions = append(actions, action{ID: "from", Label: "Pick a piece to move", Choices: from})
+	} else {
+		var to []discordgo.SelectMenuOption
+		for _, m := range legal {
+			if m.Path[0] == g.Selected && len(to) < 25 {
+				to = append(to, discordgo.SelectMenuOption{Label: m.String(), Value: m.String()})
+			}
+		}
+		actions = append(actions,
+			action{ID: "to", Label: fmt.Sprintf("Move %s…", squareName(g.Selected)), Choices: to},
+			action{ID: "back", Label: "Pick another piece", Style: discordgo.SecondaryButton},
+		)
+	}
+	return append(actions, action{ID: "resign", Label: "Resign", Style: discordgo.DangerButton, AnyPlayer: true})
+}
+
+func (g *checkers) apply(playerID, actionID string) error {
+	verb, value, _ := strings.Cut(actionID, ":")
+	legal := g.Board.legalMoves(g.BlackMove)
+	switch verb {
+	case "from":
+		sq := parseSquare(value)
+		if !slices.ContainsFunc(legal, func(m checkersMove) bool { return m.Path[0] == sq }) {
+			return errors.New("That piece can't move. Remember that captures are compulsory.")
+		}
+		g.Selected = sq
+		return nil
+	case "back":
+		g.Selected = -1
+		return nil
+	case "to":
+		k := slices.IndexFunc(legal, func(m checkersMove) bool { return m.String() == value })
+		if k < 0 {
+			return errors.New("That isn't a legal move.")
+		}
+		g.move(legal[k])
+		return nil
+	case "resign":
+		g.Over, g.Reason = true, "resignation"
+		g.Winner = g.BlackID
+		if playerID == g.BlackID {
+			g.Winner = g.WhiteID
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown checkers action %q", actionID)
+}
+
+func (g *checkers) move(m checkersMove) {
+	man := g.Board[m.Path[0]] == checkersMan || g.Board[m.Path[0]] == -checkersMan
+	g.Board.play(m)
+	g.Last = m.String()
+	g.Selected = -1
+	if man || len(m.Captures) > 0 {
+		g.Quiet = 0
+	} else {
+		g.Quiet++
+	}
+	mover := g.turn()
+	g.BlackMove = !g.BlackMove
+	switch {
+	case len(g.Board.legalMoves(g.BlackMove)) == 0:
+		g.Over, g.Winner, g.Reason = true, mover, "no moves left"
+	case g.Quiet >= checkersDrawPlies:
+		g.Over, g.Reason = true, "40 moves without progress"
+	}
+}
+
+func (g *checkers) outcome() (bool, string) {
+	return g.Over, g.Winner
+}
+
+var checkersGlyphs = map[int8]string{
+	checkersMan:                 "b",
+	checkersMan * checkersKing:  "B",
+	-checkersMan:                "w",
+	-checkersMan * checkersKing: "W",
+}
+
+func (g *checkers) render() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "⚫ <@%s> vs ⚪ <@%s>\n```\n", g.BlackID, g.WhiteID)
+	for r := 7; r >= 0; r-- {
+		fmt.Fprintf(&sb, "%d ", r+1)
+		for f := range 8 {
+			sq := r*8 + f
+			switch {
+			case g.Board[sq] != 0:
+				sb.WriteString(checkersGlyphs[g.Board[sq]])
+			case checkersDark(sq):
+				sb.WriteString("·")
+			default:
+				sb.WriteString(" ")
+			}
+			sb.WriteString(" ")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("  a b c d e f g h\n```")
+	if g.Last != "" {
+		fmt.Fprintf(&sb, "\nLast move: **%s**", g.Last)
+	}
+	switch {
+	case g.Over && g.Winner != "":
+		fmt.Fprintf(&sb, "\n🎉 <@%s> wins by %s!", g.Winner, g.Reason)
+	case g.Over:
+		fmt.Fprintf(&sb, "\nDraw: %s.", g.Reason)
+	default:
+		side := "Black (b)"
+		if !g.BlackMove {
+			side = "White (w)"
+		}
+		fmt.Fprintf(&sb, "\n%s to move: <@%s>", side, g.turn())
+	}
+	return sb.String()
+}
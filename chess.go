@@ -0,0 +1,715 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var chessKind = &gameKind{
+	name:       "chess",
+	title:      "Chess",
+	minPlayers: 2,
+	maxPlayers: 2,
+	againLabel: "Rematch",
+	newGame: func(players []string) Game {
+		g := &chess{
+			WhiteID:  players[0],
+			BlackID:  players[1],
+			Pos:      newChessPosition(),
+			Selected: -1,
+			Started:  time.Now().UTC(),
+		}
+		g.Keys = []string{g.Pos.key()}
+		return g
+	},
+	decode: decodeGame[chess],
+}
+
+const (
+	chessPawn int8 = iota + 1
+	chessKnight
+	chessBishop
+	chessRook
+	chessQueen
+	chessKing
+)
+
+// Castling rights bits.
+const (
+	whiteKingside uint8 = 1 << iota
+	whiteQueenside
+	blackKingside
+	blackQueenside
+)
+
+var (
+	knightSteps = [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	kingSteps   = [][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+	bishopDirs  = [][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+	rookDirs    = [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+)
+
+// chessPosition is a board plus everything else needed to know which moves are legal.
+// Squares are numbered rank*8+file, so a1 is 0 and h8 is 63. White pieces are
+// positive and black pieces negative.
+type chessPosition struct {
+	Board     [64]int8
+	WhiteMove bool
+	Castling  uint8
+	// EnPassant is the square a pawn may capture onto en passant, or -1.
+	EnPassant int
+	Halfmove  int
+	Fullmove  int
+}
+
+type chessMove struct {
+	From, To int
+	Promo    int8
+}
+
+func newChessPosition() chessPosition {
+	p := chessPosition{
+		WhiteMove: true,
+		Castling:  whiteKingside | whiteQueenside | blackKingside | blackQueenside,
+		EnPassant: -1,
+		Fullmove:  1,
+	}
+	back := []int8{chessRook, chessKnight, chessBishop, chessQueen, chessKing, chessBishop, chessKnight, chessRook}
+	for f := range 8 {
+		p.Board[f] = back[f]
+		p.Board[8+f] = chessPawn
+		p.Board[48+f] = -chessPawn
+		p.Board[56+f] = -back[f]
+	}
+	return p
+}
+
+func squareName(s int) string {
+	return string(rune('a'+s%8)) + string(rune('1'+s/8))
+}
+
+func parseSquare(name string) int {
+	if len(name) != 2 || name[0] < 'a' || name[0] > 'h' || name[1] < '1' || name[1] > '8' {
+		return -1
+	}
+	return int(name[1]-'1')*8 + int(name[0]-'a')
+}
+
+func onBoard(f, r int) bool {
+	return f >= 0 && f < 8 && r >= 0 && r < 8
+}
+
+// side returns 1 if white is to move and -1 otherwise, matching the sign of that side's pieces.
+func (p *chessPosition) side() int8 {
+	if p.WhiteMove {
+		return 1
+	}
+	return -1
+}
+
+func abs8(n int8) int8 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// attacked reports whether any piece of the given color attacks square s.
+func (p *chessPosition) attacked(s int, byWhite bool) bool {
+	them := int8(-1)
+	if byWhite {
+		them = 1
+	}
+	f, r := s%8, s/8
+	for _, df := range []int{-1, 1} {
+		// A white pawn attacks upwards, so it sits one rank below the square it attacks.
+		pf, pr := f+df, r-int(them)
+		if onBoard(pf, pr) && p.Board[pr*8+pf] == them*chessPawn {
+			return true
+		}
+	}
+	for _, st := range knightSteps {
+		if nf, nr := f+st[0], r+st[1]; onBoard(nf, nr) && p.Board[nr*8+nf] == them*chessKnight {
+			return true
+		}
+	}
+	for _, st := range kingSteps {
+		if nf, nr := f+st[0], r+st[1]; onBoard(nf, nr) && p.Board[nr*8+nf] == them*chessKing {
+			return true
+		}
+	}
+	slide := func(dirs [][2]int, a, b int8) bool {
+		for _, d := range dirs {
+			for nf, nr := f+d[0], r+d[1]; onBoard(nf, nr); nf, nr = nf+d[0], nr+d[1] {
+				pc := p.Board[nr*8+nf]
+				if pc == 0 {
+					continue
+				}
+				if pc == them*a || pc == them*b {
+					return true
+				}
+				break
+			}
+		}
+		return false
+	}
+	return slide(bishopDirs, chessBishop, chessQueen) || slide(rookDirs, chessRook, chessQueen)
+}
+
+func (p *chessPosition) inCheck(white bool) bool {
+	kingPiece := -chessKing
+	if white {
+		kingPiece = chessKing
+	}
+	for s, pc := range p.Board {
+		if pc == kingPiece {
+			return p.attacked(s, !white)
+		}
+	}
+	return false
+}
+
+// pseudoMoves lists moves that follow the pieces' movement rules but may leave the mover's king in check.
+func (p *chessPosition) pseudoMoves() []chessMove {
+	us := p.side()
+	var moves []chessMove
+	add := func(from, to int) {
+		// Pawns reaching the last rank must promote.
+		if abs8(p.Board[from]) == chessPawn && (to/8 == 7 || to/8 == 0) {
+			for _, promo := range []int8{chessQueen, chessRook, chessBishop, chessKnight} {
+				moves = append(moves, chessMove{from, to, promo})
+			}
+			return
+		}
+		moves = append(moves, chessMove{From: from, To: to})
+	}
+	for s, pc := range p.Board {
+		if pc*us <= 0 {
+			continue
+		}
+		f, r := s%8, s/8
+		switch abs8(pc) {
+		case chessPawn:
+			dir, startRank := 1, 1
+			if us < 0 {
+				dir, startRank = -1, 6
+			}
+			if one := s + 8*dir; p.Board[one] == 0 {
+				add(s, one)
+				if two := one + 8*dir; r == startRank && p.Board[two] == 0 {
+					add(s, two)
+				}
+			}
+			for _, df := range []int{-1, 1} {
+				if !onBoard(f+df, r+dir) {
+					continue
+				}
+				t := (r+dir)*8 + f + df
+				if p.Board[t]*us < 0 || t == p.EnPassant {
+					add(s, t)
+				}
+			}
+		case chessKnight, chessKing:
+			steps := knightSteps
+			if abs8(pc) == chessKing {
+				steps = kingSteps
+			}
+			for _, st := range steps {
+				if nf, nr := f+st[0], r+st[1]; onBoard(nf, nr) && p.Board[nr*8+nf]*us <= 0 {
+					add(s, nr*8+nf)
+				}
+			}
+		default:
+			var dirs [][2]int
+			switch abs8(pc) {
+			case chessBishop:
+				dirs = bishopDirs
+			case chessRook:
+				dirs = rookDirs
+			default:
+				dirs = append(slices.Clone(bishopDirs), rookDirs...)
+			}
+			for _, d := range dirs {
+				for nf, nr := f+d[0], r+d[1]; onBoard(nf, nr); nf, nr = nf+d[0], nr+d[1] {
+					t := nr*8 + nf
+					if p.Board[t]*us > 0 {
+						break
+					}
+					add(s, t)
+					if p.Board[t] != 0 {
+						break
+					}
+				}
+			}
+		}
+	}
+	moves = append(moves, p.castlingMoves()...)
+	return moves
+}
+
+func (p *chessPosition) castlingMoves() []chessMove {
+	home, kingside, queenside := 0, whiteKingside, whiteQueenside
+	if !p.WhiteMove {
+		home, kingside, queenside = 56, blackKingside, blackQueenside
+	}
+	us := p.side()
+	if p.Board[home+4] != us*chessKing || p.inCheck(p.WhiteMove) {
+		return nil
+	}
+	var moves []chessMove
+	if p.Castling&kingside != 0 && p.Board[home+7] == us*chessRook &&
+		p.Board[home+5] == 0 && p.Board[home+6] == 0 &&
+		!p.attacked(home+5, !p.WhiteMove) && !p.attacked(home+6, !p.WhiteMove) {
+		moves = append(moves, chessMove{From: home + 4, To: home + 6})
+	}
+	if p.Castling&queenside != 0 && p.Board[home] == us*chessRook &&
+		p.Board[home+1] == 0 && p.Board[home+2] == 0 && p.Board[home+3] == 0 &&
+		!p.attacked(home+3, !p.WhiteMove) && !p.attacked(home+2, !p.WhiteMove) {
+		moves = append(moves, chessMove{From: home + 4, To: home + 2})
+	}
+	return moves
+}
+
+// play returns the position after m, which must be at least pseudo-legal.
+func (p chessPosition) play(m chessMove) chessPosition {
+	us := p.side()
+	pc := p.Board[m.From]
+	captured := p.Board[m.To]
+	switch {
+	case abs8(pc) == chessPawn && m.To == p.EnPassant:
+		// The captured pawn sits beside the capturing one, not on the target square.
+		captured = p.Board[m.From/8*8+m.To%8]
+		p.Board[m.From/8*8+m.To%8] = 0
+	case abs8(pc) == chessKing && m.To-m.From == 2:
+		p.Board[m.From+1], p.Board[m.From+3] = p.Board[m.From+3], 0
+	case abs8(pc) == chessKing && m.From-m.To == 2:
+		p.Board[m.From-1], p.Board[m.From-4] = p.Board[m.From-4], 0
+	}
+	p.Board[m.To], p.Board[m.From] = pc, 0
+	if m.Promo != 0 {
+		p.Board[m.To] = us * m.Promo
+	}
+
+	for _, s := range []int{m.From, m.To} {
+		switch s {
+		case 0:
+			p.Castling &^= whiteQueenside
+		case 7:
+			p.Castling &^= whiteKingside
+		case 56:
+			p.Castling &^= blackQueenside
+		case 63:
+			p.Castling &^= blackKingside
+		case 4:
+			p.Castling &^= whiteKingside | whiteQueenside
+		case 60:
+			p.Castling &^= blackKingside | blackQueenside
+		}
+	}
+	p.EnPassant = -1
+	if abs8(pc) == chessPawn && (m.To-m.From == 16 || m.From-m.To == 16) {
+		p.EnPassant = (m.From + m.To) / 2
+	}
+	if abs8(pc) == chessPawn || captured != 0 {
+		p.Halfmove = 0
+	} else {
+		p.Halfmove++
+	}
+	if !p.WhiteMove {
+		p.Fullmove++
+	}
+	p.WhiteMove = !p.WhiteMove
+	return p
+}
+
+func (p *chessPosition) legalMoves() []chessMove {
+	var legal []chessMove
+	for _, m := range p.pseudoMoves() {
+		next := p.play(m)
+		if !next.inCheck(p.WhiteMove) {
+			legal = append(legal, m)
+		}
+	}
+	return legal
+}
+
+// key identifies the position for repetition. The en passant square only
+// counts when a capture onto it is actually possible.
+func (p *chessPosition) key() string {
+	ep := -1
+	for _, m := range p.legalMoves() {
+		if m.To == p.EnPassant && abs8(p.Board[m.From]) == chessPawn {
+			ep = p.EnPassant
+		}
+	}
+	return fmt.Sprint(p.Board, p.WhiteMove, p.Castling, ep)
+}
+
+// insufficientMaterial reports whether neither side can possibly mate:
+// bare kings, or a king and a single minor piece against a bare king.
+func (p *chessPosition) insufficientMaterial() bool {
+	minors := 0
+	for _, pc := range p.Board {
+		switch abs8(pc) {
+		case 0, chessKing:
+		case chessBishop, chessKnight:
+			minors++
+		default:
+			return false
+		}
+	}
+	return minors <= 1
+}
+
+const chessPieceLetters = "  NBRQK"
+
+// san writes m in standard algebraic notation. legal must be the legal moves of p.
+func (p *chessPosition) san(m chessMove, legal []chessMove) string {
+	pc := abs8(p.Board[m.From])
+	var sb strings.Builder
+	switch {
+	case pc == chessKing && m.To-m.From == 2:
+		sb.WriteString("O-O")
+	case pc == chessKing && m.From-m.To == 2:
+		sb.WriteString("O-O-O")
+	default:
+		capture := p.Board[m.To] != 0 || (pc == chessPawn && m.To == p.EnPassant)
+		if pc == chessPawn {
+			if capture {
+				sb.WriteByte(squareName(m.From)[0])
+			}
+		} else {
+			sb.WriteByte(chessPieceLetters[pc])
+			sameFile, sameRank, ambiguous := false, false, false
+			for _, o := range legal {
+				if o.To != m.To || o.From == m.From || abs8(p.Board[o.From]) != pc {
+					continue
+				}
+				ambiguous = true
+				sameFile = sameFile || o.From%8 == m.From%8
+				sameRank = sameRank || o.From/8 == m.From/8
+			}
+			switch {
+			case ambiguous && !sameFile:
+				sb.WriteByte(squareName(m.From)[0])
+			case ambiguous && !sameRank:
+				sb.WriteByte(squareName(m.From)[1])
+			case ambiguous:
+				sb.WriteString(squareName(m.From))
+			}
+		}
+		if capture {
+			sb.WriteByte('x')
+		}
+		sb.WriteString(squareName(m.To))
+		if m.Promo != 0 {
+			sb.WriteByte('=')
+			sb.WriteByte(chessPieceLetters[m.Promo])
+		}
+	}
+	next := p.play(m)
+	if next.inCheck(next.WhiteMove) {
+		if len(next.legalMoves()) == 0 {
+			sb.WriteByte('#')
+		} else {
+			sb.WriteByte('+')
+		}
+	}
+	return sb.String()
+}
+
+// parseMove finds the legal move a player typed. It accepts SAN, with or
+// without check marks and in any case when that is unambiguous, and also
+// coordinate notation such as e2e4 or e7e8q.
+func (p *chessPosition) parseMove(text string) (chessMove, error) {
+	clean := func(s string) string {
+		s = strings.TrimRight(strings.TrimSpace(s), "+#!?")
+		return strings.ReplaceAll(s, "0", "O")
+	}
+	want := clean(text)
+	legal := p.legalMoves()
+	var folded []chessMove
+	for _, m := range legal {
+		san := clean(p.san(m, legal))
+		if san == want {
+			return m, nil
+		}
+		if strings.EqualFold(san, want) {
+			folded = append(folded, m)
+		}
+		uci := squareName(m.From) + squareName(m.To)
+		if m.Promo != 0 {
+			uci += strings.ToLower(string(chessPieceLetters[m.Promo]))
+		}
+		if strings.EqualFold(uci, strings.TrimSpace(text)) {
+			return m, nil
+		}
+	}
+	if len(folded) == 1 {
+		return folded[0], nil
+	}
+	return chessMove{}, fmt.Errorf("%q isn't a legal move here.", strings.TrimSpace(text))
+}
+
+type chess struct {
+	WhiteID, BlackID string
+	Pos              chessPosition
+	// Moves holds the game so far in SAN, and Keys the position key after each move
+	// (and at the start) for spotting threefold repetition.
+	Moves []string
+	Keys  []string
+	// Selected is the square of the piece picked from the menu, or -1.
+	Selected  int
+	DrawOffer string
+	Result    string
+	Reason    string
+	Started   time.Time
+}
+
+func (g *chess) players() []string { return []string{g.WhiteID, g.BlackID} }
+
+func (g *chess) turn() string {
+	if g.Pos.WhiteMove {
+		return g.WhiteID
+	}
+	return g.BlackID
+}
+
+var chessGlyphs = map[int8]string{
+	chessKing: "♔", chessQueen: "♕", chessRook: "♖", chessBishop: "♗", chessKnight: "♘", chessPawn: "♙",
+	-chessKing: "♚", -chessQueen: "♛", -chessRook: "♜", -chessBishop: "♝", -chessKnight: "♞", -chessPawn: "♟",
+}
+
+func (g *chess) actions() []action {
+	legal := g.Pos.legalMoves()
+	var actions []action
+	if g.Selected < 0 {
+		var from []discordgo.SelectMenuOption
+		for _, m := range legal {
+			name := squareName(m.From)
+			if !slices.ContainsFunc(from, func(o discordgo.SelectMenuOption) bool { return o.Value == name }) {
+				from = append(from, discordgo.SelectMenuOption{
+					Label: chessGlyphs[g.Pos.Board[m.From]] + " " + name,
+					Value: name,
+				})
+			}
+		}
+		actions = append(actions, action{ID: "from", Label: "Pick a piece to move", Choices: from})
+	} else {
+		var to []discordgo.SelectMenuOption
+		for _, m := range legal {
+			if m.From == g.Selected && len(to) < 25 {
+				san := g.Pos.san(m, legal)
+				to = append(to, discordgo.SelectMenuOption{Label: san, Value: san})
+			}
+		}
+		actions = append(actions,
+			action{ID: "to", Label: fmt.Sprintf("Move %s to…", squareName(g.Selected)), Choices: to},
+			action{ID: "back", Label: "Pick another piece", Style: discordgo.SecondaryButton},
+		)
+	}
+	actions = append(actions, action{ID: "san", Label: "Type a move", Prompt: "Your move (e.g. Nf3, O-O, exd5)"})
+	// Either player can offer, accept or resign, whoever's move it is.
+	if g.DrawOffer != "" {
+		actions = append(actions, action{ID: "accept", Label: "Accept draw", Style: discordgo.SuccessButton, AnyPlayer: true})
+	} else {
+		actions = append(actions, action{ID: "draw", Label: "Offer draw", Style: discordgo.SecondaryButton, AnyPlayer: true})
+	}
+	return append(actions, action{ID: "resign", Label: "Resign", Style: discordgo.DangerButton, AnyPlayer: true})
+}
+
+func (g *chess) apply(playerID, actionID string) error {
+	verb, value, _ := strings.Cut(actionID, ":")
+	switch verb {
+	case "from":
+		s := parseSquare(value)
+		for _, m := range g.Pos.legalMoves() {
+			if m.From == s {
+				g.Selected = s
+				return nil
+			}
+		}
+		return errors.New("That piece has no legal moves.")
+	case "back":
+		g.Selected = -1
+		return nil
+	case "to", "san":
+		m, err := g.Pos.parseMove(value)
+		if err != nil {
+			return err
+		}
+		g.move(m)
+		return nil
+	case "draw":
+		g.DrawOffer = playerID
+		return nil
+	case "accept":
+		if g.DrawOffer == "" || g.DrawOffer == playerID {
+			return errors.New("There's no draw offer to accept.")
+		}
+		g.Result, g.Reason = "1/2-1/2", "draw agreed"
+		return nil
+	case "resign":
+		if playerID == g.WhiteID {
+			g.Result, g.Reason = "0-1", "White resigned"
+		} else {
+			g.Result, g.Reason = "1-0", "Black resigned"
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown chess action %q", actionID)
+}
+
+// move plays a legal move and checks whether it ended the game.
+func (g *chess) move(m chessMove) {
+	mover := g.turn()
+	g.Moves = append(g.Moves, g.Pos.san(m, g.Pos.legalMoves()))
+	g.Pos = g.Pos.play(m)
+	g.Selected = -1
+	// Moving instead of accepting declines the opponent's draw offer.
+	if g.DrawOffer != mover {
+		g.DrawOffer = ""
+	}
+	key := g.Pos.key()
+	g.Keys = append(g.Keys, key)
+
+	switch {
+	case len(g.Pos.legalMoves()) == 0 && g.Pos.inCheck(g.Pos.WhiteMove):
+		if g.Pos.WhiteMove {
+			g.Result, g.Reason = "0-1", "checkmate"
+		} else {
+			g.Result, g.Reason = "1-0", "checkmate"
+		}
+	case len(g.Pos.legalMoves()) == 0:
+		g.Result, g.Reason = "1/2-1/2", "stalemate"
+	case g.Pos.insufficientMaterial():
+		g.Result, g.Reason = "1/2-1/2", "insufficient material"
+	case g.Pos.Halfmove >= 100:
+		g.Result, g.Reason = "1/2-1/2", "50-move rule"
+	default:
+		repeats := 0
+		for _, k := range g.Keys {
+			if k == key {
+				repeats++
+			}
+		}
+		if repeats >= 3 {
+			g.Result, g.Reason = "1/2-1/2", "threefold repetition"
+		}
+	}
+}
+
+func (g *chess) outcome() (bool, string) {
+	switch g.Result {
+	case "":
+		return false, ""
+	case "1-0":
+		return true, g.WhiteID
+	case "0-1":
+		return true, g.BlackID
+	}
+	return true, ""
+}
+
+func (g *chess) renderBoard() string {
+	var sb strings.Builder
+	sb.WriteString("```\n")
+	for r := 7; r >= 0; r-- {
+		fmt.Fprintf(&sb, "%d ", r+1)
+		for f := range 8 {
+			if glyph, ok := chessGlyphs[g.Pos.Board[r*8+f]]; ok {
+				sb.WriteString(glyph)
+			} else if (r+f)%2 == 0 {
+				sb.WriteString("·")
+			} else {
+				sb.WriteString(" ")
+			}
+			sb.WriteString(" ")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("  a b c d e f g h\n```")
+	return sb.String()
+}
+
+func (g *chess) render() string {
+	content, _ := g.message()
+	return content
+}
+
+// files attaches the PGN of a finished game when it's too long for the message.
+func (g *chess) files() []*discordgo.File {
+	if _, attach := g.message(); !attach {
+		return nil
+	}
+	return []*discordgo.File{{Name: "game.pgn", ContentType: "application/x-chess-pgn", Reader: strings.NewReader(g.pgn())}}
+}
+
+// message lays out the game, reporting whether a finished game's PGN didn't
+// fit and needs attaching.
+func (g *chess) message() (string, bool) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "♔ <@%s> vs ♚ <@%s>\n", g.WhiteID, g.BlackID)
+	board := g.renderBoard()
+	if len(g.Moves) > 0 {
+		board += fmt.Sprintf("\nLast move: **%s**", g.Moves[len(g.Moves)-1])
+	}
+	if over, _ := g.outcome(); over {
+		sb.WriteString(board)
+		fmt.Fprintf(&sb, "\n**%s** (%s)", g.Result, g.Reason)
+		// Long games' PGN doesn't fit in a message alongside the board.
+		pgn := fmt.Sprintf("\n```\n%s\n```", g.pgn())
+		if sb.Len()+len(pgn) > 2000 {
+			sb.WriteString("\nThe PGN is attached.")
+			return sb.String(), true
+		}
+		sb.WriteString(pgn)
+		return sb.String(), false
+	}
+	sb.WriteString(board)
+	side := "White"
+	if !g.Pos.WhiteMove {
+		side = "Black"
+	}
+	fmt.Fprintf(&sb, "\n%s to move: <@%s>", side, g.turn())
+	if g.Pos.inCheck(g.Pos.WhiteMove) {
+		sb.WriteString(" — **check!**")
+	}
+	if g.DrawOffer != "" {
+		fmt.Fprintf(&sb, "\n<@%s> offers a draw.", g.DrawOffer)
+	}
+	return sb.String(), false
+}
+
+// pgn exports the game in Portable Game Notation.
+func (g *chess) pgn() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[Event \"Casual game\"]\n[Site \"Discord\"]\n[Date \"%s\"]\n", g.Started.Format("2006.01.02"))
+	fmt.Fprintf(&sb, "[White \"%s\"]\n[Black \"%s\"]\n[Result \"%s\"]\n\n", g.WhiteID, g.BlackID, g.Result)
+	for k, m := range g.Moves {
+		if k%2 == 0 {
+			fmt.Fprintf(&sb, "%d. ", k/2+1)
+		}
+		sb.WriteString(m)
+		sb.WriteString(" ")
+	}
+	sb.WriteString(g.Result)
+	return sb.String()
+}
+
+func handleChess(s *discordgo.Session, i *discordgo.InteractionCreate, om optionMap) {
+	opponent := om["opponent"].UserValue(s)
+	switch {
+	case opponent.ID == interactionUserID(i):
+		respondEphemeral(s, i, "You can't challenge yourself!")
+	case opponent.Bot:
+		respondEphemeral(s, i, "Bots don't play chess (yet).")
+	default:
+		host.open(s, i, chessKind.name, opponent.ID)
+	}
+}
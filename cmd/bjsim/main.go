@@ -0,0 +1,118 @@
+// Command bjsim plays a large number of hands at the bot's blackjack table
+// and reports the house edge, so rule changes can be checked before shipping.
+//
+//	go run ./cmd/bjsim -hands 5000000 -strategy basic -s17
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+
+	bj "geofbot/blackjack"
+)
+
+type strategy func(cards []string, upcard string) string
+
+// hitBelow hits until the hand totals at least n, ignoring the dealer.
+func hitBelow(n int) strategy {
+	return func(cards []string, upcard string) string {
+		if bj.HandScore(cards) < n {
+			return "hit"
+		}
+		return "stay"
+	}
+}
+
+func main() {
+	hands := flag.Int("hands", 1000000, "number of hands to play")
+	decks := flag.Int("decks", 1, "decks in the shoe")
+	penetration := flag.Float64("penetration", 0.75, "fraction of the shoe dealt before reshuffling")
+	strat := flag.String("strategy", "basic", "player strategy: basic, mimic (hit below 17 like the dealer) or stand")
+	standOn := flag.Int("stand", 15, "with -strategy stand, the total to stand on")
+	s17 := flag.Bool("s17", false, "dealer stands on soft 17 (the bot's table hits it)")
+	ties := flag.String("ties", "win", "how ties pay: win (the bot's table), push or lose")
+	flag.Parse()
+
+	var play strategy
+	switch *strat {
+	case "basic":
+		play = bj.BasicStrategy
+	case "mimic":
+		play = hitBelow(17)
+	case "stand":
+		play = hitBelow(*standOn)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown strategy %q\n", *strat)
+		os.Exit(2)
+	}
+	tiePays, ok := map[string]float64{"win": 1, "push": 0, "lose": -1}[*ties]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown tie rule %q\n", *ties)
+		os.Exit(2)
+	}
+	if *hands < 2 || *decks < 1 || *penetration <= 0 || *penetration > 1 {
+		fmt.Fprintln(os.Stderr, "need at least 2 hands, 1 deck and a penetration in (0, 1]")
+		os.Exit(2)
+	}
+	rules := bj.Rules{StandSoft17: *s17}
+
+	// Leave enough cards behind the cut card to finish any hand.
+	cut := max(int(float64(52**decks)*(1-*penetration)), 30)
+	var shoe bj.Deck
+	var wins, losses, tied, playerBusts, dealerBusts int
+	var sum, sumSq float64
+	for range *hands {
+		if len(shoe) < cut {
+			shoe = bj.NewShoe(*decks)
+		}
+		h := bj.Deal(shoe)
+		h.Rules = rules
+		for h.Result == bj.Playing {
+			if play(h.PlayerCards, h.DealerCards[1]) == "hit" {
+				h.Hit()
+			} else {
+				h.Stay()
+			}
+		}
+		shoe = h.Deck
+
+		var ret float64
+		switch h.Result {
+		case bj.PlayerWin:
+			wins++
+			ret = 1
+		case bj.DealerWin:
+			losses++
+			ret = -1
+		case bj.Tie:
+			tied++
+			ret = tiePays
+		}
+		if bj.HandScore(h.PlayerCards) > 21 {
+			playerBusts++
+		}
+		if bj.HandScore(h.DealerCards) > 21 {
+			dealerBusts++
+		}
+		sum += ret
+		sumSq += ret * ret
+	}
+
+	n := float64(*hands)
+	mean := sum / n
+	variance := (sumSq - n*mean*mean) / (n - 1)
+	stderr := math.Sqrt(variance / n)
+	pct := func(k int) float64 { return 100 * float64(k) / n }
+	fmt.Printf("hands         %d (%d decks, %.0f%% penetration, dealer %s soft 17, ties %s)\n",
+		*hands, *decks, 100**penetration, map[bool]string{false: "hits", true: "stands on"}[*s17], *ties)
+	fmt.Printf("strategy      %s\n", *strat)
+	fmt.Printf("player wins   %6.2f%%\n", pct(wins))
+	fmt.Printf("dealer wins   %6.2f%%\n", pct(losses))
+	fmt.Printf("ties          %6.2f%%\n", pct(tied))
+	fmt.Printf("player busts  %6.2f%%\n", pct(playerBusts))
+	fmt.Printf("dealer busts  %6.2f%%\n", pct(dealerBusts))
+	fmt.Printf("house edge    %+6.3f%% ± %.3f%% (95%%)\n", -100*mean, 196*stderr)
+	fmt.Printf("variance      %.4f per hand (std dev %.4f)\n", variance, math.Sqrt(variance))
+}
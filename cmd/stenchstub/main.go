@@ -0,0 +1,90 @@
+// Command stenchstub is a stand-in for the stench interpreter that speaks the
+// same protocol, for running the bot's !eval locally without the real
+// interpreter. Each program is piped to a command, sh by default, and its
+// output and exit status are sent back. Programs run in a fresh process every
+// time, so sessions and resets are accepted but have no effect.
+//
+//	go run ./cmd/stenchstub -addr :4040 -cmd python3
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+
+	"geofbot/stench"
+)
+
+func main() {
+	addr := flag.String("addr", ":4040", "address to listen on")
+	command := flag.String("cmd", "sh", "command that runs a program read from stdin")
+	timeout := flag.Duration("timeout", 5*time.Second, "how long a program may run")
+	flag.Parse()
+
+	args := strings.Fields(*command)
+	if len(args) == 0 {
+		log.Fatal("-cmd is empty")
+	}
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("listening on", l.Addr())
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			log.Fatal(err)
+		}
+		go serve(conn, args, *timeout)
+	}
+}
+
+func serve(conn net.Conn, args []string, timeout time.Duration) {
+	defer conn.Close()
+	dec := json.NewDecoder(bufio.NewReader(conn))
+	enc := json.NewEncoder(conn)
+	for {
+		var req stench.Request
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+		if err := enc.Encode(run(req, args, timeout)); err != nil {
+			return
+		}
+	}
+}
+
+func run(req stench.Request, args []string, timeout time.Duration) stench.Response {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader(req.Code)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	// Don't wait on children of a killed program that still hold its output open.
+	cmd.WaitDelay = 100 * time.Millisecond
+	resp := stench.Response{ID: req.ID}
+	err := cmd.Run()
+	var exit *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		resp.Exit = -1
+		fmt.Fprintf(&stderr, "timed out after %s\n", timeout)
+	case errors.As(err, &exit):
+		resp.Exit = exit.ExitCode()
+	case err != nil:
+		resp.Exit = -1
+		fmt.Fprintln(&stderr, err)
+	}
+	resp.Stdout, resp.Stderr = stdout.String(), stderr.String()
+	return resp
+}
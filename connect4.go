@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -24,38 +26,118 @@ const (
 	yellowTurn c4result = "Yellow's turn"
 )
 
-var connect4Games = make(map[string]*connect4)
+var connect4Kind = &gameKind{
+	name:       "c4",
+	title:      "Connect 4",
+	minPlayers: 2,
+	maxPlayers: 2,
+	againLabel: "Rematch",
+	newGame: func(players []string) Game {
+		return &connect4{
+			RedID:    players[0],
+			YellowID: players[1],
+			Result:   redTurn,
+			Turn:     red,
+		}
+	},
+	decode: decodeGame[connect4],
+}
 
 type connect4 struct {
 	ID              string
-	redID, yellowID string // Player IDs
-	board           [6][7]color
+	RedID, YellowID string // Player IDs
+	Board           [6][7]color
 	Result          c4result
-	turn            color
+	Turn            color
+}
+
+func (g *connect4) players() []string { return []string{g.RedID, g.YellowID} }
+
+func (g *connect4) turn() string {
+	if g.Turn == red {
+		return g.RedID
+	}
+	return g.YellowID
+}
+
+func (g *connect4) actions() []action {
+	var actions []action
+	for c := range 7 {
+		if g.Board[5][c] == empty {
+			actions = append(actions, action{ID: strconv.Itoa(c), Label: strconv.Itoa(c + 1)})
+		}
+	}
+	return actions
+}
+
+func (g *connect4) apply(playerID, actionID string) error {
+	col, err := strconv.Atoi(actionID)
+	if err != nil || col < 0 || col >= 7 || g.Board[5][col] != empty {
+		return errors.New("That column is full!")
+	}
+	g.makeMove(playerID, col)
+	if g.Result != redWin && g.Result != yellowWin {
+		if g.isFull() {
+			g.Result = draw
+		} else if g.Turn == red {
+			g.Result = redTurn
+		} else {
+			g.Result = yellowTurn
+		}
+	}
+	return nil
+}
+
+func (g *connect4) outcome() (bool, string) {
+	switch g.Result {
+	case redWin:
+		return true, g.RedID
+	case yellowWin:
+		return true, g.YellowID
+	case draw:
+		return true, ""
+	}
+	return false, ""
+}
+
+func (g *connect4) render() string {
+	header := fmt.Sprintf("🔴 <@%s> vs 🟡 <@%s>\n\n%s\n", g.RedID, g.YellowID, g.renderBoard())
+	switch g.Result {
+	case redWin:
+		return header + "🔴 Red wins!"
+	case yellowWin:
+		return header + "🟡 Yellow wins!"
+	case draw:
+		return header + "It's a draw!"
+	case yellowTurn:
+		return header + "🟡 Yellow's turn!"
+	default:
+		return header + "🔴 Red's turn!"
+	}
 }
 
 func (g *connect4) makeMove(playerID string, column int) {
 	if g.Result == waiting ||
-		(g.turn == red && playerID != g.redID) ||
-		(g.turn == yellow && playerID != g.yellowID) {
+		(g.Turn == red && playerID != g.RedID) ||
+		(g.Turn == yellow && playerID != g.YellowID) {
 		return
 	}
-	if column < 0 || column >= 7 || g.board[5][column] != empty {
+	if column < 0 || column >= 7 || g.Board[5][column] != empty {
 		return
 	}
 	row := 5
-	for row > 0 && g.board[row-1][column] == empty {
+	for row > 0 && g.Board[row-1][column] == empty {
 		row--
 	}
-	g.board[row][column] = g.turn
+	g.Board[row][column] = g.Turn
 
-	g.turn = g.turn%2 + 1
+	g.Turn = g.Turn%2 + 1
 	g.scanForWin()
 }
 
 func (g *connect4) isFull() bool {
 	for i := range 7 {
-		if g.board[5][i] == empty {
+		if g.Board[5][i] == empty {
 			return false
 		}
 	}
@@ -66,7 +148,7 @@ func (g *connect4) renderBoard() string {
 	var sb strings.Builder
 	for r := 5; r >= 0; r-- {
 		for c := 0; c < 7; c++ {
-			switch g.board[r][c] {
+			switch g.Board[r][c] {
 			case empty:
 				sb.WriteString("⚫")
 			case red:
@@ -83,57 +165,25 @@ func (g *connect4) renderBoard() string {
 
 // scan for win by the player who just made a move
 func (g *connect4) scanForWin() {
-	type (
-		coords struct {
-			x, y int
-		}
-		qNode struct {
-			directionStreak int
-			streak          int
-			coords          coords
-		}
-	)
-	team := g.turn%2 + 1
-	visited := make(map[coords]map[int]int)
-	queue := make([]qNode, 0)
-	for i := range g.board {
-		if g.board[0][i] == team {
-			queue = append(queue, qNode{-1, 1, coords{0, i}})
-		}
-	}
-	for len(queue) > 0 {
-		cur := queue[0]
-		m, ok := visited[cur.coords]
-		if !ok {
-			visited[cur.coords] = make(map[int]int)
-			m = visited[cur.coords]
-		}
-		m[cur.directionStreak] = cur.streak
-		queue = queue[1:]
-		fmt.Println(cur)
-		if cur.streak >= 4 {
-			switch team {
-			case red:
-				g.Result = redWin
-			case yellow:
-				g.Result = yellowWin
-			}
-			return
-		}
-		toCheck := []coords{
-			{cur.coords.x, cur.coords.y + 1},
-			{cur.coords.x + 1, cur.coords.y + 1},
-			{cur.coords.x + 1, cur.coords.y},
-			{cur.coords.x + 1, cur.coords.y - 1},
-		}
-		for i, coords := range toCheck {
-			if coords.x >= 0 && coords.x < 7 && coords.y >= 0 &&
-				coords.y < 7 && g.board[coords.x][coords.y] == team && visited[coords][i] < cur.streak+1 {
-				qn := qNode{i, 2, coords}
-				if cur.directionStreak == i {
-					qn = qNode{cur.directionStreak, cur.streak + 1, coords}
+	team := g.Turn%2 + 1
+	directions := [][2]int{{0, 1}, {1, 1}, {1, 0}, {1, -1}}
+	for r := range g.Board {
+		for c := range g.Board[r] {
+			for _, d := range directions {
+				n := 0
+				for x, y := r, c; x >= 0 && x < len(g.Board) && y >= 0 && y < len(g.Board[0]) && g.Board[x][y] == team; x, y = x+d[0], y+d[1] {
+					n++
+				}
+				if n < 4 {
+					continue
+				}
+				switch team {
+				case red:
+					g.Result = redWin
+				case yellow:
+					g.Result = yellowWin
 				}
-				queue = append(queue, qn)
+				return
 			}
 		}
 	}
@@ -0,0 +1,468 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	rollsFile       = "rolls.json"
+	maxDicePerTerm  = 100
+	maxDiceSides    = 1000
+	maxDiceRolled   = 500
+	maxSavedRolls   = 25
+	maxRollNameSize = 32
+)
+
+// die is one die as it was rolled. Dropped dice don't count towards the total;
+// rerolled dice were replaced by the die after them.
+type die struct {
+	Value    int
+	Dropped  bool
+	Rerolled bool
+	Exploded bool
+}
+
+// diceRoller evaluates a dice expression such as "4d6kh3+2" as it parses it.
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = factor { ("*" | "/") factor }
+//	factor = "-" factor | "(" expr ")" | dice | number
+//	dice   = [number] "d" (number | "%" | "F") { modifier }
+//
+// Modifiers are kh/kl/dh/dl n (keep or drop the highest or lowest n, plain k
+// and d meaning kh and dl), ! for exploding dice, and r/ro for rerolling
+// repeatedly or once. ! and r take a compare point such as >5, <2 or =1.
+type diceRoller struct {
+	src    string
+	pos    int
+	rolled int
+	// lines is the per-die breakdown of every dice term, in order.
+	lines []string
+}
+
+func rollDice(expr string) (int, []string, error) {
+	r := &diceRoller{src: strings.ToLower(strings.ReplaceAll(expr, " ", ""))}
+	if r.src == "" {
+		return 0, nil, errors.New("Give me something to roll, like `2d6+3`.")
+	}
+	total, err := r.expr()
+	if err == nil && r.pos < len(r.src) {
+		err = fmt.Errorf("I didn't understand `%s`.", r.src[r.pos:])
+	}
+	return total, r.lines, err
+}
+
+func (r *diceRoller) peek() byte {
+	if r.pos < len(r.src) {
+		return r.src[r.pos]
+	}
+	return 0
+}
+
+func (r *diceRoller) accept(prefix string) bool {
+	if strings.HasPrefix(r.src[r.pos:], prefix) {
+		r.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (r *diceRoller) number() (int, bool) {
+	start := r.pos
+	for r.peek() >= '0' && r.peek() <= '9' {
+		r.pos++
+	}
+	if start == r.pos {
+		return 0, false
+	}
+	n, err := strconv.Atoi(r.src[start:r.pos])
+	return n, err == nil && n <= 1_000_000
+}
+
+func (r *diceRoller) expr() (int, error) {
+	total, err := r.term()
+	for err == nil {
+		switch {
+		case r.accept("+"):
+			var n int
+			n, err = r.term()
+			total += n
+		case r.accept("-"):
+			var n int
+			n, err = r.term()
+			total -= n
+		default:
+			return total, nil
+		}
+	}
+	return 0, err
+}
+
+func (r *diceRoller) term() (int, error) {
+	total, err := r.factor()
+	for err == nil {
+		switch {
+		case r.accept("*"):
+			var n int
+			n, err = r.factor()
+			total *= n
+		case r.accept("/"):
+			var n int
+			n, err = r.factor()
+			if err == nil && n == 0 {
+				err = errors.New("Can't divide by zero.")
+			}
+			if err == nil {
+				total /= n
+			}
+		default:
+			return total, nil
+		}
+	}
+	return 0, err
+}
+
+func (r *diceRoller) factor() (int, error) {
+	switch {
+	case r.accept("-"):
+		n, err := r.factor()
+		return -n, err
+	case r.accept("("):
+		n, err := r.expr()
+		if err == nil && !r.accept(")") {
+			err = errors.New("Missing a closing bracket.")
+		}
+		return n, err
+	}
+	start := r.pos
+	count, hasCount := r.number()
+	if r.peek() != 'd' {
+		if !hasCount {
+			if r.pos >= len(r.src) {
+				return 0, errors.New("The expression ends too soon.")
+			}
+			return 0, fmt.Errorf("I didn't understand `%s`.", r.src[r.pos:])
+		}
+		return count, nil
+	}
+	r.pos++
+	if !hasCount {
+		count = 1
+	}
+	return r.dice(start, count)
+}
+
+// comparePoint reads an optional <n, >n or =n, defaulting to matching def.
+func (r *diceRoller) comparePoint(def int, required bool) (func(int) bool, error) {
+	op := byte('=')
+	if c := r.peek(); c == '<' || c == '>' || c == '=' {
+		op = c
+		r.pos++
+	} else if !required && (c < '0' || c > '9') {
+		return func(v int) bool { return v == def }, nil
+	}
+	n, ok := r.number()
+	if !ok {
+		return nil, errors.New("Expected a number to compare against.")
+	}
+	switch op {
+	case '<':
+		return func(v int) bool { return v <= n }, nil
+	case '>':
+		return func(v int) bool { return v >= n }, nil
+	}
+	return func(v int) bool { return v == n }, nil
+}
+
+// eitherPoint matches what either compare point does, so reroll modifiers add
+// up: 5d6r1r2 rerolls 1s and 2s. a may be nil.
+func eitherPoint(a, b func(int) bool) func(int) bool {
+	if a == nil || b == nil {
+		return b
+	}
+	return func(v int) bool { return a(v) || b(v) }
+}
+
+func (r *diceRoller) dice(start, count int) (int, error) {
+	lo, hi := 1, 0
+	switch {
+	case r.accept("f"):
+		lo, hi = -1, 1
+	case r.accept("%"):
+		hi = 100
+	default:
+		sides, ok := r.number()
+		if !ok || sides < 1 {
+			return 0, errors.New("Dice need a number of sides, like `d20`.")
+		}
+		hi = sides
+	}
+	if count < 1 || count > maxDicePerTerm || hi > maxDiceSides {
+		return 0, fmt.Errorf("Roll between 1 and %d dice of at most %d sides at a time.", maxDicePerTerm, maxDiceSides)
+	}
+
+	var explode, reroll func(int) bool
+	var rerollOnce bool
+	keep, keepHigh, drop, dropHigh := -1, false, -1, false
+	// keepDrops counts keep and drop modifiers; a roll may only have one.
+	keepDrops := 0
+	for modifiers := true; modifiers; {
+		var err error
+		var point func(int) bool
+		switch {
+		case r.accept("!"):
+			explode, err = r.comparePoint(hi, false)
+		case r.accept("ro"):
+			rerollOnce = true
+			point, err = r.comparePoint(0, true)
+			reroll = eitherPoint(reroll, point)
+		case r.accept("r"):
+			point, err = r.comparePoint(0, true)
+			reroll = eitherPoint(reroll, point)
+		case r.accept("kh"):
+			keep, keepHigh = r.count(), true
+			keepDrops++
+		case r.accept("kl"):
+			keep = r.count()
+			keepDrops++
+		case r.accept("k"):
+			keep, keepHigh = r.count(), true
+			keepDrops++
+		case r.accept("dh"):
+			drop, dropHigh = r.count(), true
+			keepDrops++
+		case r.accept("dl"):
+			drop = r.count()
+			keepDrops++
+		case r.pos+1 < len(r.src) && r.src[r.pos] == 'd' && r.src[r.pos+1] >= '0' && r.src[r.pos+1] <= '9':
+			r.pos++
+			drop = r.count()
+			keepDrops++
+		default:
+			modifiers = false
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if keepDrops > 1 {
+		return 0, errors.New("Use one keep or drop modifier per roll, like `4d6kh3`.")
+	}
+
+	if explode != nil && explode(lo) && explode(hi) {
+		return 0, errors.New("Those dice would explode forever.")
+	}
+	if reroll != nil && !rerollOnce {
+		all := true
+		for v := lo; v <= hi; v++ {
+			all = all && reroll(v)
+		}
+		if all {
+			return 0, errors.New("Those dice would be rerolled forever.")
+		}
+	}
+
+	var dice []die
+	for range count {
+		for {
+			if r.rolled++; r.rolled > maxDiceRolled {
+				return 0, fmt.Errorf("That's more than %d dice.", maxDiceRolled)
+			}
+			d := die{Value: lo + rand.Intn(hi-lo+1)}
+			if reroll != nil && reroll(d.Value) && !(rerollOnce && len(dice) > 0 && dice[len(dice)-1].Rerolled) {
+				d.Rerolled = true
+				dice = append(dice, d)
+				continue
+			}
+			if explode != nil && explode(d.Value) {
+				d.Exploded = true
+				dice = append(dice, d)
+				continue
+			}
+			dice = append(dice, d)
+			break
+		}
+	}
+
+	// Keep and drop apply to the dice that still count.
+	var live []int
+	for k, d := range dice {
+		if !d.Rerolled {
+			live = append(live, k)
+		}
+	}
+	sort.SliceStable(live, func(a, b int) bool { return dice[live[a]].Value < dice[live[b]].Value })
+	switch {
+	case keep >= 0 && keepHigh:
+		live = live[:max(len(live)-keep, 0)]
+	case keep >= 0:
+		live = live[min(keep, len(live)):]
+	case drop >= 0 && dropHigh:
+		live = live[max(len(live)-drop, 0):]
+	case drop >= 0:
+		live = live[:min(drop, len(live))]
+	default:
+		live = nil
+	}
+	for _, k := range live {
+		dice[k].Dropped = true
+	}
+
+	total := 0
+	parts := make([]string, len(dice))
+	for k, d := range dice {
+		v := strconv.Itoa(d.Value)
+		if lo == -1 {
+			v = []string{"−", "0", "+"}[d.Value+1]
+		}
+		if d.Exploded {
+			v += "!"
+		}
+		if d.Rerolled || d.Dropped {
+			v = "~~" + v + "~~"
+		} else {
+			total += d.Value
+		}
+		parts[k] = v
+	}
+	r.lines = append(r.lines, fmt.Sprintf("`%s` [%s] = %d", r.src[start:r.pos], strings.Join(parts, ", "), total))
+	return total, nil
+}
+
+// count reads the number after a keep or drop modifier, which defaults to 1.
+func (r *diceRoller) count() int {
+	if n, ok := r.number(); ok {
+		return n
+	}
+	return 1
+}
+
+var (
+	// savedRolls maps user ID to roll name to expression.
+	savedRolls   map[string]map[string]string
+	savedRollsMu sync.Mutex
+)
+
+func loadRolls() {
+	savedRollsMu.Lock()
+	defer savedRollsMu.Unlock()
+	savedRolls = make(map[string]map[string]string)
+	if err := loadJSON(rollsFile, &savedRolls); err != nil {
+		fmt.Println("loadRolls error:", err)
+	}
+}
+
+func saveRoll(userID, name, expr string) error {
+	savedRollsMu.Lock()
+	defer savedRollsMu.Unlock()
+	rolls := savedRolls[userID]
+	if rolls == nil {
+		rolls = make(map[string]string)
+		savedRolls[userID] = rolls
+	}
+	if _, ok := rolls[name]; !ok && len(rolls) >= maxSavedRolls {
+		return fmt.Errorf("You can save at most %d rolls.", maxSavedRolls)
+	}
+	rolls[name] = expr
+	return saveJSON(rollsFile, savedRolls)
+}
+
+func savedRoll(userID, name string) (string, bool) {
+	savedRollsMu.Lock()
+	defer savedRollsMu.Unlock()
+	expr, ok := savedRolls[userID][strings.ToLower(name)]
+	return expr, ok
+}
+
+// handleRoll rolls an expression or one of the user's saved rolls, and saves it
+// under a name if asked to.
+func handleRoll(s *discordgo.Session, i *discordgo.InteractionCreate, om optionMap) {
+	userID := interactionUserID(i)
+	expr := om["dice"].StringValue()
+	label := expr
+	if saved, ok := savedRoll(userID, expr); ok {
+		label = fmt.Sprintf("%s (%s)", expr, saved)
+		expr = saved
+	}
+	total, lines, err := rollDice(expr)
+	if err != nil {
+		respondEphemeral(s, i, err.Error())
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🎲 <@%s> rolled `%s`\n", userID, label)
+	for _, line := range lines {
+		sb.WriteString(line + "\n")
+	}
+	fmt.Fprintf(&sb, "**Total: %d**", total)
+	if opt, ok := om["save"]; ok {
+		name := strings.ToLower(strings.TrimSpace(opt.StringValue()))
+		switch {
+		case name == "" || len(name) > maxRollNameSize || strings.ContainsAny(name, " `"):
+			sb.WriteString("\nRoll names must be one word of at most 32 characters.")
+		default:
+			if err := saveRoll(userID, name, expr); err != nil {
+				sb.WriteString("\n" + err.Error())
+			} else {
+				fmt.Fprintf(&sb, "\nSaved as `%s`.", name)
+			}
+		}
+	}
+	content := sb.String()
+	if len(content) > 2000 {
+		content = fmt.Sprintf("🎲 <@%s> rolled `%s`\n**Total: %d**", userID, label, total)
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: content},
+	})
+	if err != nil {
+		fmt.Println("handleRoll respond error:", err)
+	}
+}
+
+// handleRolls lists a user's saved rolls, or deletes one.
+func handleRolls(s *discordgo.Session, i *discordgo.InteractionCreate, om optionMap) {
+	userID := interactionUserID(i)
+	savedRollsMu.Lock()
+	defer savedRollsMu.Unlock()
+	rolls := savedRolls[userID]
+	if opt, ok := om["delete"]; ok {
+		name := strings.ToLower(opt.StringValue())
+		if _, ok := rolls[name]; !ok {
+			respondEphemeral(s, i, fmt.Sprintf("You don't have a roll called `%s`.", name))
+			return
+		}
+		delete(rolls, name)
+		if err := saveJSON(rollsFile, savedRolls); err != nil {
+			fmt.Println("rolls save error:", err)
+		}
+		respondEphemeral(s, i, fmt.Sprintf("Deleted `%s`.", name))
+		return
+	}
+	if len(rolls) == 0 {
+		respondEphemeral(s, i, "You have no saved rolls. Save one with `/roll dice:2d20kh1 save:advantage`.")
+		return
+	}
+	names := make([]string, 0, len(rolls))
+	for name := range rolls {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var sb strings.Builder
+	sb.WriteString("**Your saved rolls**\n")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "`%s` · `%s`\n", name, rolls[name])
+	}
+	respondEphemeral(s, i, sb.String())
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	evalFile = "evals.json"
+	// evalKeep is how long a /eval reply's Run again and Edit buttons keep working.
+	evalKeep = 7 * 24 * time.Hour
+)
+
+// evalProgram is a program run with /eval, kept so its reply's buttons can run
+// or edit it again. Only its author may use them.
+type evalProgram struct {
+	Code string
+	// Backend is the backend picked with /eval's option, if any.
+	Backend string
+	UserID  string
+	Created time.Time
+}
+
+var (
+	// evalPrograms maps program ID to program.
+	evalPrograms   map[string]*evalProgram
+	evalProgramsMu sync.Mutex
+)
+
+func loadEvals() {
+	evalProgramsMu.Lock()
+	defer evalProgramsMu.Unlock()
+	evalPrograms = make(map[string]*evalProgram)
+	if err := loadJSON(evalFile, &evalPrograms); err != nil {
+		fmt.Println("loadEvals error:", err)
+	}
+}
+
+// saveEvalProgram stores a program, forgetting ones too old to rerun, and returns its ID.
+func saveEvalProgram(code, backend, userID string) string {
+	evalProgramsMu.Lock()
+	defer evalProgramsMu.Unlock()
+	for id, p := range evalPrograms {
+		if time.Since(p.Created) > evalKeep {
+			delete(evalPrograms, id)
+		}
+	}
+	id := newGameID()
+	evalPrograms[id] = &evalProgram{Code: code, Backend: backend, UserID: userID, Created: time.Now()}
+	if err := saveJSON(evalFile, evalPrograms); err != nil {
+		fmt.Println("eval save error:", err)
+	}
+	return id
+}
+
+func lookupEvalProgram(id string) *evalProgram {
+	evalProgramsMu.Lock()
+	defer evalProgramsMu.Unlock()
+	return evalPrograms[id]
+}
+
+// parseEvalProgram pulls the program out of a message. A fenced code block is
+// taken verbatim, with its language tag picking the backend; otherwise the
+// whole text is the program.
+func parseEvalProgram(text string) (lang, code string) {
+	start := strings.Index(text, "```")
+	if start < 0 {
+		return "", strings.TrimSpace(strings.Trim(strings.TrimSpace(text), "`"))
+	}
+	body := text[start+3:]
+	if end := strings.Index(body, "```"); end >= 0 {
+		body = body[:end]
+	}
+	// The first line is a language tag if it's a single word, as in ```py.
+	if first, rest, ok := strings.Cut(body, "\n"); ok && !strings.ContainsAny(strings.TrimSpace(first), " \t") {
+		lang, body = strings.ToLower(strings.TrimSpace(first)), rest
+	}
+	return lang, strings.TrimRight(body, " \t\n")
+}
+
+// handleEvalMessage runs !eval. The program is the rest of the message, or,
+// when that's empty and the message is a reply, the replied-to message.
+// "!eval --reset" starts the sender's session afresh on the program's backend
+// before running anything.
+func handleEvalMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
+	reply := func(content string) {
+		if _, err := s.ChannelMessageSendReply(m.ChannelID, content, m.Reference()); err != nil {
+			fmt.Println("eval send error:", err)
+		}
+	}
+	key, label := evalSessionKey(m.GuildID, m.Author.ID, m.ChannelID)
+	text := strings.TrimSpace(strings.TrimPrefix(m.Content, "!eval"))
+	reset := false
+	if rest, ok := strings.CutPrefix(text, "--reset"); ok && (rest == "" || strings.IndexFunc(rest, unicode.IsSpace) == 0) {
+		reset, text = true, strings.TrimSpace(rest)
+	} else if text == "" && m.MessageReference != nil {
+		ref := m.ReferencedMessage
+		if ref == nil {
+			var err error
+			if ref, err = s.ChannelMessage(m.MessageReference.ChannelID, m.MessageReference.MessageID); err != nil {
+				fmt.Println("eval reply fetch error:", err)
+			}
+		}
+		if ref != nil {
+			text = ref.Content
+		}
+	}
+	lang, code := parseEvalProgram(text)
+	if code == "" && !reset {
+		reply("Give me some code after `!eval`, or reply to a message with code in it.")
+		return
+	}
+	backend, err := evalBackend(lang)
+	if err != nil {
+		reply("⚠️ " + err.Error())
+		return
+	}
+	// Only the backend the program runs on is reset, so one that's down
+	// doesn't stop the others' users starting afresh.
+	if reset {
+		if err := resetEvalSession(key, backend); err != nil {
+			reply("⚠️ " + err.Error())
+			return
+		}
+		if code == "" {
+			reply(fmt.Sprintf("🧹 Started a fresh session for %s.", label))
+			return
+		}
+	}
+
+	// When every interpreter is busy, say so and fill the reply in once it's our turn.
+	var queuedMsg *discordgo.Message
+	touchEvalSession(key, label, m.GuildID)
+	resp, err := backend.eval(key, code, func(position int) {
+		queuedMsg, _ = s.ChannelMessageSendReply(m.ChannelID, fmt.Sprintf("⏳ Busy, you're #%d in the queue.", position), m.Reference())
+	})
+	out := renderEvalOutput(formatEval(resp), err, "")
+	if queuedMsg != nil {
+		_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         queuedMsg.ID,
+			Channel:    m.ChannelID,
+			Content:    &out.content,
+			Embeds:     &out.embeds,
+			Components: &out.components,
+			Files:      out.files,
+		})
+	} else {
+		_, err = s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+			Content:    out.content,
+			Embeds:     out.embeds,
+			Components: out.components,
+			Files:      out.files,
+			Reference:  m.Reference(),
+		})
+	}
+	if err != nil {
+		fmt.Println("eval send error:", err)
+	}
+}
+
+// evalModal asks for a program for a backend, prefilled with code when editing one.
+func evalModal(backend, code string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: "eval-submit-" + backend,
+			Title:    "Evaluate",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:  "value",
+						Label:     "Code",
+						Style:     discordgo.TextInputParagraph,
+						Value:     code,
+						Required:  true,
+						MaxLength: 4000,
+					},
+				}},
+			},
+		},
+	}
+}
+
+func handleEvalCommand(s *discordgo.Session, i *discordgo.InteractionCreate, om optionMap) {
+	var backend string
+	if opt, ok := om["backend"]; ok {
+		backend = strings.ToLower(opt.StringValue())
+		if _, err := evalBackend(backend); err != nil {
+			respondEphemeral(s, i, "⚠️ "+err.Error())
+			return
+		}
+	}
+	if err := s.InteractionRespond(i.Interaction, evalModal(backend, "")); err != nil {
+		fmt.Println("eval modal error:", err)
+	}
+}
+
+// handleEvalInteraction handles the /eval modal and the Run again and Edit
+// buttons on its replies.
+func handleEvalInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
+	parts := strings.Split(customID, "-")
+	if len(parts) != 3 {
+		return
+	}
+	verb := parts[1]
+	var code, backendName, id string
+	if verb == "submit" {
+		code, backendName = modalValue(i.ModalSubmitData(), "value"), parts[2]
+	} else {
+		p := lookupEvalProgram(parts[2])
+		if p == nil {
+			respondEphemeral(s, i, "This program is too old to run again. Use /eval.")
+			return
+		}
+		if p.UserID != interactionUserID(i) {
+			respondEphemeral(s, i, "Only whoever wrote this program can run or edit it. Use /eval to run your own.")
+			return
+		}
+		code, backendName, id = p.Code, p.Backend, parts[2]
+	}
+	if strings.TrimSpace(code) == "" {
+		respondEphemeral(s, i, "There's no code to run.")
+		return
+	}
+
+	if verb == "edit" {
+		if err := s.InteractionRespond(i.Interaction, evalModal(backendName, code)); err != nil {
+			fmt.Println("eval modal error:", err)
+		}
+		return
+	}
+	// A backend picked with /eval's option wins over a code block's language tag.
+	lang, program := parseEvalProgram(code)
+	if backendName != "" {
+		lang = backendName
+	}
+	backend, err := evalBackend(lang)
+	if err != nil {
+		respondEphemeral(s, i, "⚠️ "+err.Error())
+		return
+	}
+	// A rerun replaces the output it was pressed on; a submitted modal gets a reply of its own.
+	deferred := discordgo.InteractionResponseDeferredChannelMessageWithSource
+	if i.Type == discordgo.InteractionMessageComponent {
+		deferred = discordgo.InteractionResponseDeferredMessageUpdate
+	}
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: deferred}); err != nil {
+		fmt.Println("eval defer error:", err)
+		return
+	}
+
+	key, label := evalSessionKey(i.GuildID, interactionUserID(i), i.ChannelID)
+	touchEvalSession(key, label, i.GuildID)
+	resp, err := backend.eval(key, program, func(position int) {
+		queued := fmt.Sprintf("⏳ Busy, you're #%d in the queue.", position)
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &queued})
+	})
+	if id == "" {
+		id = saveEvalProgram(code, backendName, interactionUserID(i))
+	}
+	out := renderEvalOutput(formatEval(resp), err, id)
+	// Clearing the attachments drops a file left by the run being replaced.
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:     &out.content,
+		Embeds:      &out.embeds,
+		Components:  &out.components,
+		Files:       out.files,
+		Attachments: &[]*discordgo.MessageAttachment{},
+	})
+	if err != nil {
+		fmt.Println("eval edit error:", err)
+	}
+}
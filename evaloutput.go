@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// evalPageSize and evalPageLines keep a page readable and well inside an
+	// embed's description limit.
+	evalPageSize  = 1800
+	evalPageLines = 30
+	// evalMaxPages is how many pages of output get paged through before it's
+	// attached as a file instead.
+	evalMaxPages = 10
+	// evalPagesKeep is how long Prev and Next keep working.
+	evalPagesKeep = time.Hour
+)
+
+var (
+	// ansiSGR matches the colour and style codes Discord's ansi code blocks
+	// render; ansiEscape matches any escape sequence.
+	ansiSGR    = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	ansiEscape = regexp.MustCompile(`\x1b(\[[0-9;?]*[ -/]*[@-~]|[@-Z\\-_])`)
+)
+
+// evalPaged is long output being paged through. It isn't persisted; after a
+// restart its buttons say the output has expired.
+type evalPaged struct {
+	Pages []string
+	Lang  string
+	// ProgramID is the /eval program whose Run again and Edit buttons sit
+	// beside the page buttons, if any.
+	ProgramID string
+	Created   time.Time
+}
+
+var (
+	// evalPagedOutputs maps ID to paged output.
+	evalPagedOutputs   = make(map[string]*evalPaged)
+	evalPagedOutputsMu sync.Mutex
+)
+
+// evalOutput is an evaluation's reply, ready to send or edit in.
+type evalOutput struct {
+	content    string
+	embeds     []*discordgo.MessageEmbed
+	components []discordgo.MessageComponent
+	files      []*discordgo.File
+}
+
+// renderEvalOutput lays output out for Discord: in a code block if it fits,
+// as pages of embeds if it doesn't, and as an attached file if it's longer
+// still. Output with colour codes goes in an ansi block. programID, if set,
+// adds Run again and Edit buttons for that /eval program.
+func renderEvalOutput(output string, err error, programID string) evalOutput {
+	// Empty rather than nil embeds, so editing this in clears any a previous run left.
+	noEmbeds := []*discordgo.MessageEmbed{}
+	if err != nil {
+		return evalOutput{content: "⚠️ " + err.Error(), embeds: noEmbeds, components: evalButtons(programID, "", 0, 0)}
+	}
+	lang := ""
+	if strings.Contains(output, "\x1b[") {
+		lang = "ansi"
+		// Keep the codes the block renders and drop the rest, like cursor movement.
+		output = ansiEscape.ReplaceAllStringFunc(output, func(seq string) string {
+			if ansiSGR.MatchString(seq) {
+				return seq
+			}
+			return ""
+		})
+	}
+	pages := paginate(output)
+	switch {
+	case len(pages) == 1:
+		return evalOutput{content: codeBlock(lang, pages[0]), embeds: noEmbeds, components: evalButtons(programID, "", 0, 0)}
+
+	case len(pages) <= evalMaxPages:
+		id := newGameID()
+		evalPagedOutputsMu.Lock()
+		for id, p := range evalPagedOutputs {
+			if time.Since(p.Created) > evalPagesKeep {
+				delete(evalPagedOutputs, id)
+			}
+		}
+		evalPagedOutputs[id] = &evalPaged{Pages: pages, Lang: lang, ProgramID: programID, Created: time.Now()}
+		evalPagedOutputsMu.Unlock()
+		return evalOutput{
+			embeds:     []*discordgo.MessageEmbed{evalPageEmbed(pages, lang, 0)},
+			components: evalButtons(programID, id, 0, len(pages)),
+		}
+	}
+
+	plain := ansiEscape.ReplaceAllString(output, "")
+	return evalOutput{
+		content: fmt.Sprintf("📎 The output is %d lines long, so it's attached. It starts:\n%s",
+			strings.Count(plain, "\n")+1, codeBlock(lang, pages[0])),
+		embeds:     noEmbeds,
+		components: evalButtons(programID, "", 0, 0),
+		files:      []*discordgo.File{{Name: "output.txt", ContentType: "text/plain", Reader: strings.NewReader(plain)}},
+	}
+}
+
+// paginate splits output into pages at line breaks, breaking lines that are
+// too long for a page on their own.
+func paginate(output string) []string {
+	var pages []string
+	var page strings.Builder
+	lines := 0
+	flush := func() {
+		pages = append(pages, strings.TrimSuffix(page.String(), "\n"))
+		page.Reset()
+		lines = 0
+	}
+	for _, line := range strings.SplitAfter(output, "\n") {
+		for len(line) > evalPageSize {
+			cut := evalPageSize
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			if page.Len() > 0 {
+				flush()
+			}
+			page.WriteString(line[:cut] + "\n")
+			flush()
+			line = line[cut:]
+		}
+		if page.Len()+len(line) > evalPageSize || lines == evalPageLines {
+			flush()
+		}
+		page.WriteString(line)
+		lines++
+	}
+	if page.Len() > 0 || len(pages) == 0 {
+		flush()
+	}
+	return pages
+}
+
+// codeBlock fences text, breaking up fences inside it so they can't close the block early.
+func codeBlock(lang, text string) string {
+	return "```" + lang + "\n" + strings.ReplaceAll(text, "```", "`​``") + "\n```"
+}
+
+func evalPageEmbed(pages []string, lang string, page int) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Description: codeBlock(lang, pages[page]),
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Page %d/%d", page+1, len(pages))},
+	}
+}
+
+// evalButtons returns the buttons under an eval reply: Prev and Next when it
+// has pages, and Run again and Edit when it's from /eval.
+func evalButtons(programID, pagedID string, page, total int) []discordgo.MessageComponent {
+	var buttons []discordgo.MessageComponent
+	if pagedID != "" {
+		buttons = append(buttons,
+			discordgo.Button{Style: discordgo.SecondaryButton, Label: "◀ Prev", CustomID: fmt.Sprintf("evalpage-%s-%d", pagedID, page-1), Disabled: page == 0},
+			discordgo.Button{Style: discordgo.SecondaryButton, Label: "Next ▶", CustomID: fmt.Sprintf("evalpage-%s-%d", pagedID, page+1), Disabled: page == total-1},
+		)
+	}
+	if programID != "" {
+		buttons = append(buttons,
+			discordgo.Button{Style: discordgo.PrimaryButton, Label: "Run again", CustomID: "eval-run-" + programID},
+			discordgo.Button{Style: discordgo.SecondaryButton, Label: "Edit", CustomID: "eval-edit-" + programID},
+		)
+	}
+	if len(buttons) == 0 {
+		return []discordgo.MessageComponent{}
+	}
+	return buttonRows(buttons)
+}
+
+// handleEvalPage turns the page of long eval output.
+func handleEvalPage(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
+	parts := strings.Split(customID, "-")
+	if len(parts) != 3 {
+		return
+	}
+	page, err := strconv.Atoi(parts[2])
+	evalPagedOutputsMu.Lock()
+	p := evalPagedOutputs[parts[1]]
+	evalPagedOutputsMu.Unlock()
+	if p == nil {
+		respondEphemeral(s, i, "This output has expired. Run the program again to see it.")
+		return
+	}
+	if err != nil || page < 0 || page >= len(p.Pages) {
+		return
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{evalPageEmbed(p.Pages, p.Lang, page)},
+			Components: evalButtons(p.ProgramID, parts[1], page, len(p.Pages)),
+		},
+	})
+	if err != nil {
+		fmt.Println("eval page error:", err)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// evalSession is one interpreter session the bot has run programs in. The
+// interpreter holds the state; this is what the bot needs to list and expire it.
+type evalSession struct {
+	Key   string
+	Label string
+	// GuildID is the server the session belongs to, "" for DMs.
+	GuildID  string
+	Runs     int
+	LastUsed time.Time
+}
+
+var (
+	// evalSessions maps session key to session. Sessions live only as long as
+	// the interpreter state they name, so they aren't persisted.
+	evalSessions   = make(map[string]*evalSession)
+	evalSessionsMu sync.Mutex
+)
+
+// evalSessionKey names the session a user's programs run in: their own in
+// this server, or the channel's when sessions are shared per channel.
+func evalSessionKey(guildID, userID, channelID string) (key, label string) {
+	if *EvalSessionScope == "channel" {
+		return "channel:" + channelID, fmt.Sprintf("<#%s>", channelID)
+	}
+	return "user:" + guildID + ":" + userID, fmt.Sprintf("<@%s>", userID)
+}
+
+// visibleEvalSession reports whether /eval-session may show or clear a session
+// for this interaction: any of the server's sessions, but in DMs, which all
+// share the empty guild ID, only the caller's own.
+func visibleEvalSession(sess *evalSession, i *discordgo.InteractionCreate) bool {
+	if i.GuildID == "" {
+		key, _ := evalSessionKey("", interactionUserID(i), i.ChannelID)
+		return sess.Key == key
+	}
+	return sess.GuildID == i.GuildID
+}
+
+// touchEvalSession records a run in a session, creating it on first use.
+func touchEvalSession(key, label, guildID string) {
+	evalSessionsMu.Lock()
+	defer evalSessionsMu.Unlock()
+	sess, ok := evalSessions[key]
+	if !ok {
+		sess = &evalSession{Key: key, Label: label, GuildID: guildID}
+		evalSessions[key] = sess
+	}
+	sess.Runs++
+	sess.LastUsed = time.Now()
+}
+
+// resetEvalSession throws a session's state away in one backend, or in every
+// backend when backend is nil, and forgets it.
+func resetEvalSession(key string, backend evaluator) error {
+	evalSessionsMu.Lock()
+	delete(evalSessions, key)
+	evalSessionsMu.Unlock()
+	if backend != nil {
+		return backend.reset(key)
+	}
+	return resetEvaluators(key)
+}
+
+// expireEvalSessions resets sessions nobody has used for a while, so the
+// interpreter doesn't hold on to their state forever.
+func expireEvalSessions() {
+	for range time.Tick(time.Minute) {
+		evalSessionsMu.Lock()
+		var idle []string
+		for key, sess := range evalSessions {
+			if time.Since(sess.LastUsed) > *EvalSessionIdle {
+				idle = append(idle, key)
+			}
+		}
+		evalSessionsMu.Unlock()
+		for _, key := range idle {
+			if err := resetEvalSession(key, nil); err != nil {
+				fmt.Println("eval session expiry error:", key, err)
+			}
+		}
+	}
+}
+
+func handleEvalSession(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	if len(options) == 0 {
+		return
+	}
+	sub := options[0]
+	om := parseOptions(sub.Options)
+
+	switch sub.Name {
+	case "list":
+		evalSessionsMu.Lock()
+		var sessions []*evalSession
+		for _, sess := range evalSessions {
+			if visibleEvalSession(sess, i) {
+				sessions = append(sessions, sess)
+			}
+		}
+		evalSessionsMu.Unlock()
+		if len(sessions) == 0 {
+			respondEphemeral(s, i, "There are no eval sessions here.")
+			return
+		}
+		slices.SortFunc(sessions, func(a, b *evalSession) int { return b.LastUsed.Compare(a.LastUsed) })
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "**Eval sessions** (reset after %s idle)\n", *EvalSessionIdle)
+		for _, sess := range sessions[:min(len(sessions), 25)] {
+			fmt.Fprintf(&sb, "%s · %d runs · last used <t:%d:R>\n", sess.Label, sess.Runs, sess.LastUsed.Unix())
+		}
+		respondEphemeral(s, i, sb.String())
+
+	case "clear":
+		if opt, ok := om["all"]; ok && opt.BoolValue() {
+			if i.Member == nil || i.Member.Permissions&discordgo.PermissionManageServer == 0 {
+				respondEphemeral(s, i, "Only server managers can clear everyone's sessions.")
+				return
+			}
+			evalSessionsMu.Lock()
+			var keys []string
+			for key, sess := range evalSessions {
+				if visibleEvalSession(sess, i) {
+					keys = append(keys, key)
+				}
+			}
+			evalSessionsMu.Unlock()
+			for _, key := range keys {
+				if err := resetEvalSession(key, nil); err != nil {
+					respondEphemeral(s, i, "⚠️ "+err.Error())
+					return
+				}
+			}
+			respondEphemeral(s, i, fmt.Sprintf("🧹 Cleared %d sessions.", len(keys)))
+			return
+		}
+		key, label := evalSessionKey(i.GuildID, interactionUserID(i), i.ChannelID)
+		if err := resetEvalSession(key, nil); err != nil {
+			respondEphemeral(s, i, "⚠️ "+err.Error())
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("🧹 Cleared the session for %s.", label))
+	}
+}
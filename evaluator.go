@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"geofbot/stench"
+)
+
+// evalTimeout is the default wall clock limit on an evaluation, from sending
+// the program to getting its result.
+const evalTimeout = 10 * time.Second
+
+// evaluator runs programs for !eval and /eval. Programs in the same session
+// share state on backends that keep any.
+type evaluator interface {
+	// eval runs a program. If the backend is busy it waits its turn, calling
+	// queued first with its place in line. Errors are shown to the user as they are.
+	eval(session, code string, queued func(position int)) (stench.Response, error)
+	// reset throws away a session's state.
+	reset(session string) error
+}
+
+var (
+	// evaluators maps backend name, which is also the code block language
+	// that picks it, to backend.
+	evaluators   = make(map[string]evaluator)
+	evaluatorsMu sync.Mutex
+)
+
+func registerEvaluator(name string, e evaluator) {
+	evaluatorsMu.Lock()
+	defer evaluatorsMu.Unlock()
+	evaluators[name] = e
+}
+
+// evalBackend picks the backend for a code block's language tag, stench if there's none.
+func evalBackend(lang string) (evaluator, error) {
+	if lang == "" {
+		lang = "stench"
+	}
+	evaluatorsMu.Lock()
+	defer evaluatorsMu.Unlock()
+	if e, ok := evaluators[lang]; ok {
+		return e, nil
+	}
+	return nil, fmt.Errorf("There's no interpreter for `%s`. Try one of: %s.", lang, strings.Join(evaluatorNames(), ", "))
+}
+
+// evaluatorNames lists the backends. Callers must hold evaluatorsMu.
+func evaluatorNames() []string {
+	names := make([]string, 0, len(evaluators))
+	for name := range evaluators {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// resetEvaluators throws a session away on every backend.
+func resetEvaluators(session string) error {
+	evaluatorsMu.Lock()
+	backends := make([]evaluator, 0, len(evaluators))
+	for _, e := range evaluators {
+		backends = append(backends, e)
+	}
+	evaluatorsMu.Unlock()
+	var errs []error
+	for _, e := range backends {
+		errs = append(errs, e.reset(session))
+	}
+	return errors.Join(errs...)
+}
+
+// evalQueue limits how many programs a backend runs at once, keeping count
+// of who's waiting so they can be told their place in line.
+type evalQueue struct {
+	// slots holds a token for each evaluation in progress.
+	slots chan struct{}
+
+	mu      sync.Mutex
+	waiting int
+}
+
+func newEvalQueue(concurrency int) evalQueue {
+	return evalQueue{slots: make(chan struct{}, max(concurrency, 1))}
+}
+
+// acquire waits for a free slot, calling queued with the caller's place in
+// line if it has to wait.
+func (q *evalQueue) acquire(queued func(position int)) {
+	select {
+	case q.slots <- struct{}{}:
+		return
+	default:
+	}
+	q.mu.Lock()
+	q.waiting++
+	position := q.waiting
+	q.mu.Unlock()
+	if queued != nil {
+		queued(position)
+	}
+	q.slots <- struct{}{}
+	q.mu.Lock()
+	q.waiting--
+	q.mu.Unlock()
+}
+
+func (q *evalQueue) release() {
+	<-q.slots
+}
+
+// subprocessEvaluator runs each program in a fresh, sandboxed process of a
+// configured command, with the program on stdin. It keeps no state between programs.
+type subprocessEvaluator struct {
+	evalQueue
+	args   []string
+	limits evalLimits
+}
+
+func newSubprocessEvaluator(args []string, limits evalLimits, concurrency int) *subprocessEvaluator {
+	return &subprocessEvaluator{evalQueue: newEvalQueue(concurrency), args: args, limits: limits}
+}
+
+func (e *subprocessEvaluator) eval(session, code string, queued func(position int)) (stench.Response, error) {
+	e.acquire(queued)
+	defer e.release()
+	return runSandboxed(e.args, code, e.limits)
+}
+
+func (e *subprocessEvaluator) reset(session string) error { return nil }
+
+// evalConfig is where the eval backends come from.
+type evalConfig struct {
+	StenchCmd  []string
+	StenchAddr string
+	// Runners maps backend name to the command that runs its programs.
+	Runners map[string][]string
+	Limits  evalLimits
+	// StenchLimits apply to the stench server as a whole, except Wall, which
+	// applies to each of its evaluations.
+	StenchLimits evalLimits
+}
+
+// loadEvalConfig reads the eval settings from the config file, after the app
+// ID, token and guild lines:
+//
+//	stench.cmd:escript stench -s
+//	stench.addr::4040
+//	eval.python:python3 -
+//	limit.cpu:5s
+//	limit.memory:256M
+//	stench.limit.memory:1G
+//
+// Each eval.<name> line adds a backend picked with a ```<name> code block.
+// The limit.<name> lines (cpu, wall, memory, output and cgroup) set the
+// sandbox limits for those backends' programs. The stench.limit.<name> lines
+// set the stench server's; it uses limit.cgroup unless given its own.
+func loadEvalConfig() evalConfig {
+	cfg := evalConfig{
+		StenchCmd:    []string{"escript", "stench", "-s"},
+		StenchAddr:   stenchAddr,
+		Runners:      make(map[string][]string),
+		Limits:       defaultEvalLimits,
+		StenchLimits: defaultStenchLimits,
+	}
+	content, err := os.ReadFile(".config")
+	if err != nil {
+		return cfg
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(string(content), "\r", ""), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch name, isRunner := strings.CutPrefix(key, "eval."); {
+		case key == "stench.cmd" && len(strings.Fields(value)) > 0:
+			cfg.StenchCmd = strings.Fields(value)
+		case key == "stench.addr":
+			cfg.StenchAddr = value
+		case isRunner && name != "" && !strings.ContainsAny(name, "- ") && len(strings.Fields(value)) > 0:
+			cfg.Runners[strings.ToLower(name)] = strings.Fields(value)
+		case isRunner:
+			fmt.Println("eval config: skipping bad backend line", line)
+		case strings.HasPrefix(key, "stench.limit."):
+			if err := cfg.StenchLimits.setLimit(strings.TrimPrefix(key, "stench.limit."), strings.TrimSpace(value)); err != nil {
+				fmt.Println("eval config:", err)
+			}
+		case strings.HasPrefix(key, "limit."):
+			if err := cfg.Limits.setLimit(strings.TrimPrefix(key, "limit."), strings.TrimSpace(value)); err != nil {
+				fmt.Println("eval config:", err)
+			}
+		}
+	}
+	if cfg.StenchLimits.Cgroup == "" {
+		cfg.StenchLimits.Cgroup = cfg.Limits.Cgroup
+	}
+	return cfg
+}
@@ -0,0 +1,773 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Game is a turn-based game run by the gameHost. The host takes care of lobbies,
+// turn enforcement, buttons and persistence, so a Game only implements the rules.
+// Exported fields of a Game are what gets persisted.
+type Game interface {
+	// players returns the IDs of the seated players in turn order.
+	players() []string
+	// turn returns the ID of the player expected to act, or "" if any seated player may act.
+	turn() string
+	// actions returns the legal actions for the player whose turn it is.
+	actions() []action
+	// apply performs an action for a player, returning an error if it isn't legal.
+	apply(playerID, actionID string) error
+	// render formats the message content for the current state.
+	render() string
+	// outcome reports whether the game is over and who won; winner is "" for a draw or a house win.
+	outcome() (over bool, winner string)
+}
+
+// action is a move a player can make, shown as a button. Disabled actions are
+// shown but can't be taken, which lets a game lay its board out as buttons.
+type action struct {
+	ID       string
+	Label    string
+	Style    discordgo.ButtonStyle
+	Disabled bool
+	// NewRow starts a new row of buttons at this action.
+	NewRow bool
+	// Prompt, if set, asks the player for a value in a modal first. The value
+	// reaches apply as "<ID>:<value>".
+	Prompt string
+	// Choices, if set, shows the action as a select menu on its own row, with
+	// Label as the placeholder. The picked value reaches apply as "<ID>:<value>".
+	Choices []discordgo.SelectMenuOption
+	// AnyPlayer lets every player take the action, not only the one whose
+	// turn it is, such as resigning or answering a draw offer.
+	AnyPlayer bool
+}
+
+// privateViewer is implemented by games with information only one player may
+// see, such as a hand of cards. The host offers a button that shows it
+// ephemerally. Actions returned with the view are applied like any other
+// action, after which the view is refreshed in place and the shared message updated.
+type privateViewer interface {
+	privateView(playerID string) (string, []action)
+}
+
+// botPlayer is implemented by games that can play a seat themselves. The bot
+// sits in a seat under the bot's own user ID.
+type botPlayer interface {
+	// botMove returns the action the bot takes on its turn.
+	botMove() string
+}
+
+// attacher is implemented by games that send files with their message, such
+// as a record of a finished game too long to show inline. The files replace
+// any the message already had.
+type attacher interface {
+	files() []*discordgo.File
+}
+
+// gameKind describes how the host creates and restores one type of game.
+type gameKind struct {
+	// name prefixes the CustomIDs of the game's buttons, so it must not contain '-'.
+	name       string
+	title      string
+	minPlayers int
+	maxPlayers int
+	againLabel string
+	// peekLabel labels the button that shows a player their private view.
+	peekLabel string
+	newGame   func(players []string) Game
+	decode    func(data []byte) (Game, error)
+}
+
+// decodeGame restores a persisted game of concrete type T.
+func decodeGame[T any, P interface {
+	*T
+	Game
+}](data []byte) (Game, error) {
+	var g T
+	err := json.Unmarshal(data, &g)
+	return P(&g), err
+}
+
+// hostedGame is a game, or its lobby before it starts, along with the host's bookkeeping.
+type hostedGame struct {
+	ID        string
+	Kind      string
+	ChannelID string
+	OwnerID   string
+	Lobby     []string
+	Rematch   bool
+	// MessageID is the shared channel message showing the game.
+	MessageID string
+	State     json.RawMessage
+	EndedAt   time.Time
+
+	game Game
+	// onEnd, if set, is called once the game is over.
+	onEnd func(s *discordgo.Session, g Game)
+}
+
+type gameHost struct {
+	mu    sync.Mutex
+	kinds map[string]*gameKind
+	games map[string]*hostedGame
+}
+
+const (
+	gamesFile = "games.json"
+	// finished games are kept this long so their rematch buttons keep working.
+	finishedGameTTL = 24 * time.Hour
+)
+
+var host = newGameHost(append([]*gameKind{blackjackKind, blackjackTrainerKind, connect4Kind, tictactoeKind, ultimateKind, pokerKind, hangmanKind, chessKind, battleshipKind, checkersKind, reversiKind, unoKind}, minesweeperKinds...)...)
+
+var gameSeq atomic.Uint64
+
+func newGameHost(kinds ...*gameKind) *gameHost {
+	h := &gameHost{
+		kinds: make(map[string]*gameKind),
+		games: make(map[string]*hostedGame),
+	}
+	for _, k := range kinds {
+		h.kinds[k.name] = k
+	}
+	return h
+}
+
+// newGameID returns a unique ID that is safe to embed in a CustomID.
+func newGameID() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + strconv.FormatUint(gameSeq.Add(1), 36)
+}
+
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.User == nil {
+		return i.Member.User.ID
+	}
+	return i.User.ID
+}
+
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		fmt.Println("respondEphemeral error:", err)
+	}
+}
+
+// buttonRows lays buttons out five to a row, dropping any past Discord's limit of five rows.
+func buttonRows(buttons []discordgo.MessageComponent) []discordgo.MessageComponent {
+	var rows []discordgo.MessageComponent
+	for len(buttons) > 0 && len(rows) < 5 {
+		n := min(5, len(buttons))
+		rows = append(rows, discordgo.ActionsRow{Components: buttons[:n]})
+		buttons = buttons[n:]
+	}
+	return rows
+}
+
+// actionRows lays actions out as buttons, five to a row unless an action asks
+// for a new row. Private actions belong to a player's ephemeral view.
+func actionRows(kindName, gameID string, actions []action, private bool) []discordgo.MessageComponent {
+	act, ask := "act", "ask"
+	if private {
+		act, ask = "pact", "pask"
+	}
+	var rows []discordgo.MessageComponent
+	var row []discordgo.MessageComponent
+	for _, a := range actions {
+		if len(row) == 5 || ((a.NewRow || a.Choices != nil) && len(row) > 0) {
+			rows = append(rows, discordgo.ActionsRow{Components: row})
+			row = nil
+		}
+		if a.Choices != nil {
+			rows = append(rows, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    fmt.Sprintf("%s-%s-%s-%s", kindName, act, gameID, a.ID),
+					Placeholder: a.Label,
+					Options:     a.Choices,
+					Disabled:    a.Disabled,
+				},
+			}})
+			continue
+		}
+		style := a.Style
+		if style == 0 {
+			style = discordgo.PrimaryButton
+		}
+		verb := act
+		if a.Prompt != "" {
+			verb = ask
+		}
+		row = append(row, discordgo.Button{
+			Style:    style,
+			Label:    a.Label,
+			CustomID: fmt.Sprintf("%s-%s-%s-%s", kindName, verb, gameID, a.ID),
+			Disabled: a.Disabled,
+		})
+	}
+	if len(row) > 0 {
+		rows = append(rows, discordgo.ActionsRow{Components: row})
+	}
+	if len(rows) > 5 {
+		rows = rows[:5]
+	}
+	return rows
+}
+
+// load restores persisted games, skipping any that no longer decode.
+func (h *gameHost) load() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	saved := make(map[string]*hostedGame)
+	if err := loadJSON(gamesFile, &saved); err != nil {
+		fmt.Println("gameHost load error:", err)
+		return
+	}
+	for id, hg := range saved {
+		kind, ok := h.kinds[hg.Kind]
+		if !ok {
+			continue
+		}
+		if len(hg.State) > 0 {
+			g, err := kind.decode(hg.State)
+			if err != nil {
+				fmt.Println("gameHost decode error:", id, err)
+				continue
+			}
+			hg.game = g
+		}
+		h.games[id] = hg
+	}
+}
+
+// save persists every game. Callers must hold h.mu.
+func (h *gameHost) save() {
+	for id, hg := range h.games {
+		if !hg.EndedAt.IsZero() && time.Since(hg.EndedAt) > finishedGameTTL {
+			delete(h.games, id)
+			continue
+		}
+		if hg.game == nil {
+			hg.State = nil
+			continue
+		}
+		state, err := json.Marshal(hg.game)
+		if err != nil {
+			fmt.Println("gameHost save error:", id, err)
+			continue
+		}
+		hg.State = state
+	}
+	if err := saveJSON(gamesFile, h.games); err != nil {
+		fmt.Println("gameHost save error:", err)
+	}
+}
+
+// open handles the slash command for a game kind. Any opponents given are
+// seated straight away; once the table is full the game starts, otherwise a
+// lobby opens for others to join.
+func (h *gameHost) open(s *discordgo.Session, i *discordgo.InteractionCreate, kindName string, opponents ...string) {
+	userID := interactionUserID(i)
+	h.mu.Lock()
+	kind := h.kinds[kindName]
+	hg := &hostedGame{
+		ID:        newGameID(),
+		Kind:      kind.name,
+		ChannelID: i.ChannelID,
+		OwnerID:   userID,
+		Lobby:     append([]string{userID}, opponents...),
+		Rematch:   true,
+	}
+	if len(hg.Lobby) >= kind.maxPlayers {
+		hg.start(kind)
+		hg.runBots(s.State.User.ID)
+	}
+	h.games[hg.ID] = hg
+	content, components := hg.message(kind)
+	h.save()
+	h.mu.Unlock()
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: components,
+		},
+	})
+	if err != nil {
+		fmt.Println("gameHost open respond error:", err)
+		return
+	}
+	if m, err := s.InteractionResponse(i.Interaction); err == nil {
+		h.mu.Lock()
+		hg.MessageID = m.ID
+		h.mu.Unlock()
+	}
+}
+
+// challenge seats the opponent picked in the command straight away, or opens a
+// lobby for anyone to join when there isn't one.
+func (h *gameHost) challenge(s *discordgo.Session, i *discordgo.InteractionCreate, om optionMap, kindName string) {
+	opt, ok := om["opponent"]
+	if !ok {
+		h.open(s, i, kindName)
+		return
+	}
+	opponent := opt.UserValue(s)
+	switch {
+	case opponent.ID == interactionUserID(i):
+		respondEphemeral(s, i, "You can't challenge yourself!")
+	case opponent.Bot:
+		respondEphemeral(s, i, "Bots can't play this game.")
+	default:
+		h.open(s, i, kindName, opponent.ID)
+	}
+}
+
+// spawn starts a game between players without a lobby, posts it to a channel
+// and returns its ID. Spawned games don't offer a rematch, since whoever
+// spawned them owns what happens next.
+func (h *gameHost) spawn(s *discordgo.Session, kindName, channelID string, players []string, onEnd func(s *discordgo.Session, g Game)) string {
+	h.mu.Lock()
+	kind := h.kinds[kindName]
+	hg := &hostedGame{
+		ID:        newGameID(),
+		Kind:      kind.name,
+		ChannelID: channelID,
+		OwnerID:   players[0],
+		Lobby:     players,
+		onEnd:     onEnd,
+	}
+	hg.start(kind)
+	h.games[hg.ID] = hg
+	content, components := hg.message(kind)
+	h.save()
+	h.mu.Unlock()
+
+	m, err := s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:    content,
+		Components: components,
+	})
+	if err != nil {
+		fmt.Println("gameHost spawn error:", err)
+		return hg.ID
+	}
+	h.mu.Lock()
+	hg.MessageID = m.ID
+	h.mu.Unlock()
+	return hg.ID
+}
+
+// setOnEnd reconnects a restored game to whatever is waiting for it to end,
+// which isn't persisted. It reports false if there's no such game.
+func (h *gameHost) setOnEnd(gameID string, onEnd func(s *discordgo.Session, g Game)) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hg, ok := h.games[gameID]
+	if ok {
+		hg.onEnd = onEnd
+	}
+	return ok
+}
+
+func (hg *hostedGame) start(kind *gameKind) {
+	hg.game = kind.newGame(hg.Lobby)
+	hg.EndedAt = time.Time{}
+}
+
+// message builds the content and buttons for the game's current state.
+func (hg *hostedGame) message(kind *gameKind) (string, []discordgo.MessageComponent) {
+	if hg.game == nil {
+		mentions := make([]string, len(hg.Lobby))
+		for k, p := range hg.Lobby {
+			mentions[k] = fmt.Sprintf("<@%s>", p)
+		}
+		content := fmt.Sprintf("<@%s> wants to play %s! Click Join to play. (%d/%d)\nPlayers: %s",
+			hg.OwnerID, kind.title, len(hg.Lobby), kind.maxPlayers, strings.Join(mentions, ", "))
+		buttons := []discordgo.MessageComponent{
+			discordgo.Button{
+				Style:    discordgo.SuccessButton,
+				Label:    "Join Game",
+				CustomID: fmt.Sprintf("%s-join-%s", kind.name, hg.ID),
+			},
+		}
+		if kind.minPlayers < kind.maxPlayers {
+			buttons = append(buttons, discordgo.Button{
+				Style:    discordgo.PrimaryButton,
+				Label:    "Start",
+				CustomID: fmt.Sprintf("%s-start-%s", kind.name, hg.ID),
+				Disabled: len(hg.Lobby) < kind.minPlayers,
+			})
+		}
+		return content, buttonRows(buttons)
+	}
+
+	content := hg.game.render()
+	if over, _ := hg.game.outcome(); over {
+		if !hg.Rematch {
+			return content, []discordgo.MessageComponent{}
+		}
+		return content, buttonRows([]discordgo.MessageComponent{
+			discordgo.Button{
+				Style:    discordgo.PrimaryButton,
+				Label:    kind.againLabel,
+				CustomID: fmt.Sprintf("%s-again-%s", kind.name, hg.ID),
+			},
+		})
+	}
+	rows := actionRows(kind.name, hg.ID, hg.game.actions(), false)
+	if _, ok := hg.game.(privateViewer); ok && len(rows) < 5 {
+		rows = append(rows, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Style:    discordgo.SecondaryButton,
+				Label:    kind.peekLabel,
+				CustomID: fmt.Sprintf("%s-peek-%s", kind.name, hg.ID),
+			},
+		}})
+	}
+	return content, rows
+}
+
+// privateMessage builds a player's ephemeral view of the game.
+func (hg *hostedGame) privateMessage(kind *gameKind, pv privateViewer, userID string) (string, []discordgo.MessageComponent) {
+	content, actions := pv.privateView(userID)
+	if over, _ := hg.game.outcome(); over {
+		actions = nil
+	}
+	return content, actionRows(kind.name, hg.ID, actions, true)
+}
+
+// modalValue returns the value of the text input with the given CustomID in a submitted modal.
+func modalValue(data discordgo.ModalSubmitInteractionData, inputID string) string {
+	for _, c := range data.Components {
+		row, ok := c.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, rc := range row.Components {
+			if input, ok := rc.(*discordgo.TextInput); ok && input.CustomID == inputID {
+				return input.Value
+			}
+		}
+	}
+	return ""
+}
+
+// handleModal applies a prompted action once the player submits its modal. It
+// reports whether the modal belonged to a hosted game kind.
+func (h *gameHost) handleModal(s *discordgo.Session, i *discordgo.InteractionCreate) bool {
+	data := i.ModalSubmitData()
+	return h.dispatch(s, i, data.CustomID, modalValue(data, "value"))
+}
+
+// handleComponent routes a button press or menu pick to the game it belongs
+// to. It reports whether the CustomID belonged to a hosted game kind.
+func (h *gameHost) handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) bool {
+	var value string
+	if values := i.MessageComponentData().Values; len(values) > 0 {
+		value = values[0]
+	}
+	return h.dispatch(s, i, customID, value)
+}
+
+// dispatch carries out a "<kind>-<verb>-<gameID>[-<actionID>]" CustomID for
+// the interacting user. value holds what the user typed into a prompt, if anything.
+func (h *gameHost) dispatch(s *discordgo.Session, i *discordgo.InteractionCreate, customID, value string) bool {
+	kindName, rest, _ := strings.Cut(customID, "-")
+	verb, rest, _ := strings.Cut(rest, "-")
+	gameID, actionID, _ := strings.Cut(rest, "-")
+	userID := interactionUserID(i)
+
+	h.mu.Lock()
+	kind, ok := h.kinds[kindName]
+	if !ok {
+		h.mu.Unlock()
+		return false
+	}
+	hg, ok := h.games[gameID]
+	if !ok || hg.Kind != kind.name {
+		h.mu.Unlock()
+		respondEphemeral(s, i, "This game is no longer available.")
+		return true
+	}
+
+	switch verb {
+	case "peek":
+		pv, ok := hg.game.(privateViewer)
+		if !ok || !slices.Contains(hg.game.players(), userID) {
+			h.mu.Unlock()
+			respondEphemeral(s, i, "You're not playing in this game.")
+			return true
+		}
+		content, components := hg.privateMessage(kind, pv, userID)
+		h.mu.Unlock()
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:    content,
+				Components: components,
+				Flags:      discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			fmt.Println("gameHost peek respond error:", err)
+		}
+		return true
+	case "ask", "pask":
+		problem := hg.check(userID, hg.anyPlayer(userID, actionID))
+		var prompt string
+		if problem == "" {
+			actions := hg.game.actions()
+			if pv, ok := hg.game.(privateViewer); ok && verb == "pask" {
+				_, actions = pv.privateView(userID)
+			}
+			for _, a := range actions {
+				if a.ID == actionID {
+					prompt = a.Prompt
+				}
+			}
+			if prompt == "" {
+				problem = "You can't do that right now."
+			}
+		}
+		h.mu.Unlock()
+		if problem != "" {
+			respondEphemeral(s, i, problem)
+			return true
+		}
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseModal,
+			Data: &discordgo.InteractionResponseData{
+				CustomID: fmt.Sprintf("%s-%s-%s-%s", kind.name, strings.TrimSuffix(verb, "ask")+"act", hg.ID, actionID),
+				Title:    kind.title,
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID: "value",
+							Label:    prompt,
+							Style:    discordgo.TextInputShort,
+							Required: true,
+						},
+					}},
+				},
+			},
+		})
+		if err != nil {
+			fmt.Println("gameHost modal respond error:", err)
+		}
+		return true
+	}
+
+	var problem string
+	var ended, private bool
+	// Everything but private actions comes from the shared message, which may
+	// have been reposted since the game was saved.
+	if verb != "pact" && i.Message != nil {
+		hg.MessageID = i.Message.ID
+	}
+	switch verb {
+	case "join":
+		switch {
+		case hg.game != nil:
+			problem = "This game has already started."
+		case userID == hg.OwnerID:
+			problem = "You can't join your own game!"
+		case slices.Contains(hg.Lobby, userID):
+			problem = "You have already joined this game."
+		default:
+			hg.Lobby = append(hg.Lobby, userID)
+			if len(hg.Lobby) == kind.maxPlayers {
+				hg.start(kind)
+			}
+		}
+	case "start":
+		switch {
+		case hg.game != nil:
+			problem = "This game has already started."
+		case userID != hg.OwnerID:
+			problem = "Only the player who opened the game can start it."
+		case len(hg.Lobby) < kind.minPlayers:
+			problem = fmt.Sprintf("At least %d players are needed to start.", kind.minPlayers)
+		default:
+			hg.start(kind)
+		}
+	case "act":
+		if value != "" {
+			actionID += ":" + value
+		}
+		problem = hg.act(userID, actionID)
+	case "pact":
+		if value != "" {
+			actionID += ":" + value
+		}
+		problem = hg.act(userID, actionID)
+		private = true
+	case "again":
+		switch {
+		case hg.game == nil || !hg.Rematch:
+			problem = "This game can't be restarted."
+		case !slices.Contains(hg.game.players(), userID):
+			problem = "You're not playing in this game."
+		default:
+			// Rotate the seats so a different player moves first.
+			players := hg.game.players()
+			hg.Lobby = append(slices.Clone(players[1:]), players[0])
+			hg.start(kind)
+		}
+	default:
+		problem = "Unknown action."
+	}
+	if problem == "" && hg.game != nil {
+		hg.runBots(s.State.User.ID)
+		ended = hg.ended()
+	}
+	content, components := hg.message(kind)
+	// Games that attach files get the message's attachments replaced each
+	// update; other games' messages are left as they are.
+	var files []*discordgo.File
+	var attachments *[]*discordgo.MessageAttachment
+	if a, ok := hg.game.(attacher); ok {
+		files, attachments = a.files(), &[]*discordgo.MessageAttachment{}
+	}
+	var privateContent string
+	var privateComponents []discordgo.MessageComponent
+	if pv, ok := hg.game.(privateViewer); ok && private {
+		privateContent, privateComponents = hg.privateMessage(kind, pv, userID)
+	}
+	onEnd, g, channelID, messageID := hg.onEnd, hg.game, hg.ChannelID, hg.MessageID
+	if problem == "" {
+		h.save()
+	}
+	h.mu.Unlock()
+
+	if problem != "" {
+		respondEphemeral(s, i, problem)
+		return true
+	}
+	if private {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:    privateContent,
+				Components: privateComponents,
+			},
+		})
+		if err != nil {
+			fmt.Println("gameHost private respond error:", err)
+		}
+		if messageID != "" {
+			_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+				ID:          messageID,
+				Channel:     channelID,
+				Content:     &content,
+				Components:  &components,
+				Files:       files,
+				Attachments: attachments,
+			})
+			if err != nil {
+				fmt.Println("gameHost shared message edit error:", err)
+			}
+		}
+	} else {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:     content,
+				Components:  components,
+				Files:       files,
+				Attachments: attachments,
+			},
+		})
+		if err != nil {
+			fmt.Println("gameHost respond error:", err)
+		}
+	}
+	if ended && onEnd != nil {
+		onEnd(s, g)
+	}
+	return true
+}
+
+// act applies a player's action, enforcing seating and turn order. It returns a
+// message for the player if the action was refused.
+func (hg *hostedGame) act(userID, actionID string) string {
+	if problem := hg.check(userID, hg.anyPlayer(userID, actionID)); problem != "" {
+		return problem
+	}
+	if err := hg.game.apply(userID, actionID); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// check reports why userID can't act in the game right now, or "" if they
+// can. anyPlayer skips the turn check.
+func (hg *hostedGame) check(userID string, anyPlayer bool) string {
+	if hg.game == nil {
+		return "This game hasn't started yet."
+	}
+	if over, _ := hg.game.outcome(); over {
+		return "This game is over."
+	}
+	if !slices.Contains(hg.game.players(), userID) {
+		return "You're not playing in this game."
+	}
+	if t := hg.game.turn(); t != "" && t != userID && !anyPlayer {
+		return "It's not your turn!"
+	}
+	return ""
+}
+
+// anyPlayer reports whether actionID, ignoring any ":<value>", is an
+// AnyPlayer action, either on the game's message or in userID's private view.
+func (hg *hostedGame) anyPlayer(userID, actionID string) bool {
+	if hg.game == nil {
+		return false
+	}
+	id, _, _ := strings.Cut(actionID, ":")
+	actions := hg.game.actions()
+	if pv, ok := hg.game.(privateViewer); ok && slices.Contains(hg.game.players(), userID) {
+		_, private := pv.privateView(userID)
+		actions = append(actions, private...)
+	}
+	return slices.ContainsFunc(actions, func(a action) bool { return a.ID == id && a.AnyPlayer })
+}
+
+// runBots lets the bot take its turns until a human is up or the game ends.
+func (hg *hostedGame) runBots(botID string) {
+	bp, ok := hg.game.(botPlayer)
+	if !ok {
+		return
+	}
+	for {
+		if over, _ := hg.game.outcome(); over || hg.game.turn() != botID {
+			return
+		}
+		if err := hg.game.apply(botID, bp.botMove()); err != nil {
+			fmt.Println("runBots error:", err)
+			return
+		}
+	}
+}
+
+// ended records when the game finished and reports whether it just did.
+func (hg *hostedGame) ended() bool {
+	if over, _ := hg.game.outcome(); !over {
+		return false
+	}
+	hg.EndedAt = time.Now()
+	return true
+}
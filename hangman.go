@@ -0,0 +1,152 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+//go:embed words/hangman.txt
+var hangmanWordList string
+
+var hangmanWords = strings.Fields(hangmanWordList)
+
+const hangmanMaxMisses = 6
+
+var hangmanKind = &gameKind{
+	name:       "hm",
+	title:      "Hangman",
+	minPlayers: 1,
+	maxPlayers: 1,
+	againLabel: "New word",
+	newGame: func(players []string) Game {
+		return &hangman{
+			PlayerID: players[0],
+			Word:     hangmanWords[rand.Intn(len(hangmanWords))],
+		}
+	},
+	decode: decodeGame[hangman],
+}
+
+type hangman struct {
+	PlayerID string
+	Word     string
+	Guessed  []string
+	// Misses counts wrong letters and wrong whole-word guesses.
+	Misses int
+	Solved bool
+}
+
+var hangmanGallows = [hangmanMaxMisses + 1]string{
+	"  +---+\n      |\n      |\n      |\n     ===",
+	"  +---+\n  O   |\n      |\n      |\n     ===",
+	"  +---+\n  O   |\n  |   |\n      |\n     ===",
+	"  +---+\n  O   |\n /|   |\n      |\n     ===",
+	"  +---+\n  O   |\n /|\\  |\n      |\n     ===",
+	"  +---+\n  O   |\n /|\\  |\n /    |\n     ===",
+	"  +---+\n  O   |\n /|\\  |\n / \\  |\n     ===",
+}
+
+func (g *hangman) players() []string { return []string{g.PlayerID} }
+
+func (g *hangman) turn() string { return g.PlayerID }
+
+// actions offers the unguessed letters in two menus, since a select menu holds at most 25 options.
+func (g *hangman) actions() []action {
+	var first, second []discordgo.SelectMenuOption
+	for c := 'a'; c <= 'z'; c++ {
+		letter := string(c)
+		if slices.Contains(g.Guessed, letter) {
+			continue
+		}
+		opt := discordgo.SelectMenuOption{Label: strings.ToUpper(letter), Value: letter}
+		if c <= 'm' {
+			first = append(first, opt)
+		} else {
+			second = append(second, opt)
+		}
+	}
+	var actions []action
+	if len(first) > 0 {
+		actions = append(actions, action{ID: "am", Label: "Guess a letter (A–M)", Choices: first})
+	}
+	if len(second) > 0 {
+		actions = append(actions, action{ID: "nz", Label: "Guess a letter (N–Z)", Choices: second})
+	}
+	return append(actions, action{ID: "word", Label: "Guess the word", Prompt: "Your guess", NewRow: true})
+}
+
+func (g *hangman) apply(playerID, actionID string) error {
+	verb, guess, _ := strings.Cut(actionID, ":")
+	guess = strings.ToLower(strings.TrimSpace(guess))
+	switch verb {
+	case "am", "nz":
+		if len(guess) != 1 || guess[0] < 'a' || guess[0] > 'z' {
+			return errors.New("Pick a single letter.")
+		}
+		if slices.Contains(g.Guessed, guess) {
+			return fmt.Errorf("You already guessed %s.", strings.ToUpper(guess))
+		}
+		g.Guessed = append(g.Guessed, guess)
+		if !strings.Contains(g.Word, guess) {
+			g.Misses++
+		}
+		g.Solved = strings.Trim(g.Word, strings.Join(g.Guessed, "")) == ""
+	case "word":
+		if guess == "" {
+			return errors.New("Type a word to guess.")
+		}
+		if guess == g.Word {
+			g.Solved = true
+		} else {
+			g.Misses++
+		}
+	default:
+		return fmt.Errorf("unknown hangman action %q", actionID)
+	}
+	return nil
+}
+
+func (g *hangman) render() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<@%s> is playing Hangman\n```\n%s\n```\n", g.PlayerID, hangmanGallows[g.Misses])
+	over, _ := g.outcome()
+	for _, c := range g.Word {
+		if over || slices.Contains(g.Guessed, string(c)) {
+			sb.WriteString(strings.ToUpper(string(c)))
+		} else {
+			sb.WriteString("\\_")
+		}
+		sb.WriteString(" ")
+	}
+	var wrong []string
+	for _, letter := range g.Guessed {
+		if !strings.Contains(g.Word, letter) {
+			wrong = append(wrong, strings.ToUpper(letter))
+		}
+	}
+	if len(wrong) > 0 {
+		fmt.Fprintf(&sb, "\nMisses: %s", strings.Join(wrong, " "))
+	}
+	switch {
+	case g.Solved:
+		sb.WriteString("\n🎉 You got it!")
+	case over:
+		sb.WriteString("\n💀 Out of guesses!")
+	default:
+		fmt.Fprintf(&sb, "\n%d wrong guesses left.", hangmanMaxMisses-g.Misses)
+	}
+	return sb.String()
+}
+
+func (g *hangman) outcome() (bool, string) {
+	if g.Solved {
+		return true, g.PlayerID
+	}
+	return g.Misses >= hangmanMaxMisses, ""
+}
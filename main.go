@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"os/signal"
-	"strconv"
+	"slices"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -45,347 +45,410 @@ var commands = []*discordgo.ApplicationCommand{
 	{
 		Name:        "blackjack",
 		Description: "play blackjack",
-		Options:     []*discordgo.ApplicationCommandOption{},
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "bet",
+				Description: "Chips to bet on each hand from now on (0 to play for fun)",
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				MinValue:    &zeroChips,
+				MaxValue:    maxWager,
+			},
+			{
+				Name:        "mode",
+				Description: "Trainer mode coaches you on basic strategy (hands are for fun)",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "normal", Value: blackjackKind.name},
+					{Name: "trainer", Value: blackjackTrainerKind.name},
+				},
+			},
+			{
+				Name:        "count",
+				Description: "Trainer mode: show the Hi-Lo count for your 6-deck shoe",
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+			},
+		},
 	},
 	{
 		Name:        "connect4",
 		Description: "play connect4",
 		Options:     []*discordgo.ApplicationCommandOption{},
 	},
-}
-
-var (
-	Token = flag.String("token", "", "Bot authentication token")
-	App   = flag.String("app", "", "Application ID")
-	Guild = flag.String("guild", "", "Guild ID")
-)
-
-func handleBlackjack(s *discordgo.Session, i *discordgo.InteractionCreate, om optionMap) {
-	// zone := tracy.Zone("handleBlackjack")
-	// defer zone.End()
-	blackjackMessage(s, i, om)
-}
-
-func connect4ColumnButtons(gameID string) []discordgo.MessageComponent {
-	row1 := make([]discordgo.MessageComponent, 5)
-	for i := 0; i < 5; i++ {
-		row1[i] = discordgo.Button{
-			Style:    discordgo.PrimaryButton,
-			Label:    fmt.Sprintf("%d", i+1),
-			CustomID: fmt.Sprintf("c4-drop-%s-%d", gameID, i),
-		}
-	}
-	row2 := make([]discordgo.MessageComponent, 2)
-	for i := 0; i < 2; i++ {
-		row2[i] = discordgo.Button{
-			Style:    discordgo.PrimaryButton,
-			Label:    fmt.Sprintf("%d", i+6),
-			CustomID: fmt.Sprintf("c4-drop-%s-%d", gameID, i+5),
-		}
-	}
-	return []discordgo.MessageComponent{
-		discordgo.ActionsRow{Components: row1},
-		discordgo.ActionsRow{Components: row2},
-	}
-}
-
-func handleConnect4(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var userID string
-	if i.User == nil {
-		userID = i.Member.User.ID
-	} else {
-		userID = i.User.ID
-	}
-	connect4Games[userID] = &connect4{
-		ID:     userID,
-		redID:  userID,
-		Result: waiting,
-		turn:   red,
-	}
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("<@%s> wants to play Connect 4! 🔴 Click Join to play as 🟡.", userID),
-			Components: []discordgo.MessageComponent{
-				discordgo.ActionsRow{
-					Components: []discordgo.MessageComponent{
-						discordgo.Button{
-							Style:    discordgo.SuccessButton,
-							Label:    "Join Game",
-							CustomID: fmt.Sprintf("c4-join-%s", userID),
-						},
-					},
+	{
+		Name:        "tictactoe",
+		Description: "play tic-tac-toe",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "variant",
+				Description: "Which game to play",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "classic", Value: tictactoeKind.name},
+					{Name: "ultimate", Value: ultimateKind.name},
 				},
 			},
+			{
+				Name:        "bot",
+				Description: "Play the bot instead: perfect in classic, a heuristic search in ultimate",
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+			},
 		},
-	})
-	if err != nil {
-		fmt.Println("handleConnect4 respond error:", err)
-	}
-}
-
-func handleConnect4Button(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
-	var userID string
-	if i.User == nil {
-		userID = i.Member.User.ID
-	} else {
-		userID = i.User.ID
-	}
-
-	switch {
-	case strings.HasPrefix(customID, "c4-join-"):
-		gameID := customID[len("c4-join-"):]
-		game, ok := connect4Games[gameID]
-		if !ok || game.Result != waiting {
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "This game is no longer available.",
-					Flags:   discordgo.MessageFlagsEphemeral,
+	},
+	{
+		Name:        "poker",
+		Description: "open a texas hold'em table for 2-9 players",
+		Options:     []*discordgo.ApplicationCommandOption{},
+	},
+	{
+		Name:        "hangman",
+		Description: "play hangman",
+		Options:     []*discordgo.ApplicationCommandOption{},
+	},
+	{
+		Name:        "wordle",
+		Description: "play today's wordle for this server",
+		Options:     []*discordgo.ApplicationCommandOption{},
+	},
+	{
+		Name:        "battleship",
+		Description: "play battleship against someone",
+		Options:     []*discordgo.ApplicationCommandOption{},
+	},
+	{
+		Name:        "minesweeper",
+		Description: "play minesweeper",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "difficulty",
+				Description: "How big a board to play",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "easy (5x4, buttons)", Value: "easy"},
+					{Name: "medium (8x8)", Value: "medium"},
+					{Name: "hard (10x10)", Value: "hard"},
 				},
-			})
-			return
-		}
-		if userID == game.redID {
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "You can't join your own game!",
-					Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			{
+				Name:        "spoiler",
+				Description: "Post a spoiler-tag board to play on your own instead",
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+			},
+		},
+	},
+	{
+		Name:        "trivia",
+		Description: "run a trivia quiz",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "start",
+				Description: "Start a quiz in this channel",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "rounds",
+						Description: "How many questions to ask",
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						MinValue:    &triviaMinRounds,
+						MaxValue:    triviaMaxRounds,
+					},
+					{
+						Name:        "pack",
+						Description: "Which question pack to use (default: all of them)",
+						Type:        discordgo.ApplicationCommandOptionString,
+					},
 				},
-			})
-			return
-		}
-		game.yellowID = userID
-		game.Result = redTurn
-		content := fmt.Sprintf("🔴 <@%s> vs 🟡 <@%s>\n\n%s\n🔴 Red's turn!", game.redID, game.yellowID, game.renderBoard())
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseUpdateMessage,
-			Data: &discordgo.InteractionResponseData{
-				Content:    content,
-				Components: connect4ColumnButtons(gameID),
-			},
-		})
-
-	case strings.HasPrefix(customID, "c4-drop-"):
-		rest := customID[len("c4-drop-"):]
-		lastHyphen := strings.LastIndex(rest, "-")
-		if lastHyphen < 0 {
-			return
-		}
-		gameID := rest[:lastHyphen]
-		col, err := strconv.Atoi(rest[lastHyphen+1:])
-		if err != nil {
-			return
-		}
-		game, ok := connect4Games[gameID]
-		if !ok {
-			return
-		}
-		if (game.turn == red && userID != game.redID) || (game.turn == yellow && userID != game.yellowID) {
-			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-				Type: discordgo.InteractionResponseChannelMessageWithSource,
-				Data: &discordgo.InteractionResponseData{
-					Content: "It's not your turn!",
-					Flags:   discordgo.MessageFlagsEphemeral,
+			},
+			{
+				Name:        "packs",
+				Description: "List the question packs",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "upload",
+				Description: "Add a question pack to this server from a JSON file",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "file",
+						Description: "The pack as JSON",
+						Type:        discordgo.ApplicationCommandOptionAttachment,
+						Required:    true,
+					},
 				},
-			})
-			return
-		}
-		game.makeMove(userID, col)
-		if game.Result != redWin && game.Result != yellowWin {
-			if game.isFull() {
-				game.Result = draw
-			} else if game.turn == red {
-				game.Result = redTurn
-			} else {
-				game.Result = yellowTurn
-			}
-		}
-		var content string
-		var components []discordgo.MessageComponent
-		switch game.Result {
-		case redWin:
-			content = fmt.Sprintf("🔴 <@%s> vs 🟡 <@%s>\n\n%s\n🔴 Red wins!", game.redID, game.yellowID, game.renderBoard())
-		case yellowWin:
-			content = fmt.Sprintf("🔴 <@%s> vs 🟡 <@%s>\n\n%s\n🟡 Yellow wins!", game.redID, game.yellowID, game.renderBoard())
-		case draw:
-			content = fmt.Sprintf("🔴 <@%s> vs 🟡 <@%s>\n\n%s\nIt's a draw!", game.redID, game.yellowID, game.renderBoard())
-		case redTurn:
-			content = fmt.Sprintf("🔴 <@%s> vs 🟡 <@%s>\n\n%s\n🔴 Red's turn!", game.redID, game.yellowID, game.renderBoard())
-			components = connect4ColumnButtons(gameID)
-		case yellowTurn:
-			content = fmt.Sprintf("🔴 <@%s> vs 🟡 <@%s>\n\n%s\n🟡 Yellow's turn!", game.redID, game.yellowID, game.renderBoard())
-			components = connect4ColumnButtons(gameID)
-		}
-		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseUpdateMessage,
-			Data: &discordgo.InteractionResponseData{
-				Content:    content,
-				Components: components,
-			},
-		})
-		if game.Result == redWin || game.Result == yellowWin || game.Result == draw {
-			delete(connect4Games, gameID)
-		}
-	}
-}
-
-func messageCreate(sh *stenchHandler) func(s *discordgo.Session, m *discordgo.MessageCreate) {
-	return func(s *discordgo.Session, m *discordgo.MessageCreate) {
-		if m.Author.ID == s.State.User.ID {
-			return
-		}
-		// zone := tracy.Zone("messageCreate")
-		// defer zone.End()
-		if strings.HasPrefix(m.Content, "/") {
-			// Ignore slash commands
-			return
-		}
-		fields := strings.Fields(m.Content)
-		fmt.Println("Fields: ", fields)
-		if len(fields) > 0 {
-			switch fields[0] {
-			case "!eval":
-				value := sh.eval(strings.Join(fields[1:], " "))
-				fmt.Println(value)
-				s.ChannelMessageSend(m.ChannelID, value)
-			}
-		}
-	}
-}
-
-func blackjackMessage(s *discordgo.Session, i *discordgo.InteractionCreate, om optionMap) {
-	// zone := tracy.Zone("blackjackMessage")
-	// defer zone.End()
-	dealerCards, playerCards := []string{}, []string{}
-	deck := newDeck()
-	deck.shuffle()
-	dealerCard, deck := deck.deal()
-	playerCard, deck := deck.deal()
-	dealerCards = append(dealerCards, dealerCard)
-	playerCards = append(playerCards, playerCard)
-	dealerCard, deck = deck.deal()
-	playerCard, deck = deck.deal()
-	dealerCards = append(dealerCards, dealerCard)
-	playerCards = append(playerCards, playerCard)
-	if i.User == nil {
-		blackjackGames[i.Member.User.ID] = &blackjack{
-			PlayerID:    i.Member.User.ID,
-			Deck:        deck,
-			DealerCards: dealerCards,
-			PlayerCards: playerCards,
-			Result:      "Playing",
-		}
-	} else {
-		blackjackGames[i.User.ID] = &blackjack{
-			PlayerID:    i.User.ID,
-			Deck:        deck,
-			DealerCards: dealerCards,
-			PlayerCards: playerCards,
-			Result:      "Playing",
-		}
-	}
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("Dealer Cards: ? + **%v**\r\nPlayer Cards: **%v**", dealerCards[1:], playerCards),
-			Components: []discordgo.MessageComponent{
-				discordgo.ActionsRow{
-					Components: []discordgo.MessageComponent{
-						discordgo.Button{
-							Style:    discordgo.DangerButton,
-							Label:    "Hit",
-							CustomID: "hit-btn",
-						},
-						discordgo.Button{
-							Style:    discordgo.DangerButton,
-							Label:    "Stay",
-							CustomID: "stay-btn",
+			},
+		},
+	},
+	{
+		Name:        "checkers",
+		Description: "play checkers",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "opponent",
+				Description: "Who to challenge (leave empty to let anyone join)",
+				Type:        discordgo.ApplicationCommandOptionUser,
+			},
+		},
+	},
+	{
+		Name:        "reversi",
+		Description: "play reversi",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "opponent",
+				Description: "Who to challenge (leave empty to let anyone join)",
+				Type:        discordgo.ApplicationCommandOptionUser,
+			},
+		},
+	},
+	{
+		Name:        "uno",
+		Description: "open an uno table for 2-8 players",
+		Options:     []*discordgo.ApplicationCommandOption{},
+	},
+	{
+		Name:        "roll",
+		Description: "roll dice, e.g. 4d6kh3+2",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "dice",
+				Description: "A dice expression or the name of a saved roll",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Required:    true,
+			},
+			{
+				Name:        "save",
+				Description: "Save this roll under a name",
+				Type:        discordgo.ApplicationCommandOptionString,
+			},
+		},
+	},
+	{
+		Name:        "rolls",
+		Description: "list or delete your saved rolls",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "delete",
+				Description: "The saved roll to delete",
+				Type:        discordgo.ApplicationCommandOptionString,
+			},
+		},
+	},
+	{
+		Name:        "eval",
+		Description: "run code in the stench interpreter or another backend",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "backend",
+				Description: "Which interpreter to use (default stench, or a code block's language)",
+				Type:        discordgo.ApplicationCommandOptionString,
+			},
+		},
+	},
+	{
+		Name:        "eval-session",
+		Description: "manage eval sessions",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "list",
+				Description: "List the eval sessions in use in this server",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "clear",
+				Description: "Clear your eval session",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "all",
+						Description: "Clear everyone's sessions in this server (server managers only)",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:        "stench",
+		Description: "manage the stench interpreter (bot admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "status",
+				Description: "Show whether the interpreter is running, and its recent logs",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "restart",
+				Description: "Restart the interpreter, clearing every eval session",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+		},
+	},
+	{
+		Name:        "wallet",
+		Description: "check your chips",
+		Options:     []*discordgo.ApplicationCommandOption{},
+	},
+	{
+		Name:        "daily",
+		Description: "collect your daily chips",
+		Options:     []*discordgo.ApplicationCommandOption{},
+	},
+	{
+		Name:        "slots",
+		Description: "spin the slot machine",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "bet",
+				Description: "Chips to bet (default 10)",
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				MinValue:    &oneChip,
+				MaxValue:    maxWager,
+			},
+		},
+	},
+	{
+		Name:        "roulette",
+		Description: "bet on a spin of the european roulette wheel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "on",
+				Description: "A number 0-36, red, black, odd, even, low, high, dozen1-3 or column1-3",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Required:    true,
+			},
+			{
+				Name:        "bet",
+				Description: "Chips to bet (default 10)",
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				MinValue:    &oneChip,
+				MaxValue:    maxWager,
+			},
+		},
+	},
+	{
+		Name:        "coinflip",
+		Description: "flip a coin against someone for chips",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "bet",
+				Description: "Chips each of you puts in (default 10)",
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				MinValue:    &oneChip,
+				MaxValue:    maxWager,
+			},
+			{
+				Name:        "opponent",
+				Description: "Who to challenge (leave empty to let anyone accept)",
+				Type:        discordgo.ApplicationCommandOptionUser,
+			},
+		},
+	},
+	{
+		Name:        "chess",
+		Description: "challenge someone to chess",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "opponent",
+				Description: "Who to play against",
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "tournament",
+		Description: "run a connect4 tournament",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "create",
+				Description: "Create a tournament in this channel",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "format",
+						Description: "Bracket format",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "single elimination", Value: string(singleElimination)},
+							{Name: "round robin", Value: string(roundRobin)},
 						},
 					},
 				},
 			},
+			{
+				Name:        "join",
+				Description: "Join the tournament in this channel",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "start",
+				Description: "Start the tournament and spawn the first round",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "bracket",
+				Description: "Show the current bracket",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        "forfeit",
+				Description: "Forfeit your match in this round, or a stalled player's if you run the tournament",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        "player",
+						Description: "Player whose match to forfeit (default: you)",
+						Type:        discordgo.ApplicationCommandOptionUser,
+					},
+				},
+			},
+			{
+				Name:        "cancel",
+				Description: "Cancel the tournament in this channel (creator or server managers)",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
 		},
-	})
-	if err != nil {
-		fmt.Println("blackjackMessage respond error:", err)
-	}
+	},
 }
 
-// buildBlackJackContent formats the message content string based on the current game state.
-func buildBlackJackContent(g *blackjack, playerScore, dealerScore int) string {
-	switch g.Result {
-	case "DealerWin":
-		return fmt.Sprintf(
-			"Dealer Cards: **%v**\r\nPlayer Cards: **%v** = **%d**\r\nDealer won with a score of %d",
-			g.DealerCards, g.PlayerCards, playerScore, dealerScore,
-		)
-	case "PlayerWin":
-		return fmt.Sprintf(
-			"Dealer Cards: **%v**\r\nPlayer Cards: **%v** = **%d**\r\nPlayer won with a score of %d",
-			g.DealerCards, g.PlayerCards, playerScore, playerScore,
-		)
-	case "Tie":
-		return fmt.Sprintf(
-			"Dealer Cards: **%v**\r\nPlayer Cards: **%v** = **%d**\r\nScores are tied at %d, so Player wins",
-			g.DealerCards, g.PlayerCards, playerScore, playerScore,
-		)
+var (
+	Token = flag.String("token", "", "Bot authentication token")
+	App   = flag.String("app", "", "Application ID")
+	Guild = flag.String("guild", "", "Guild ID")
+	// Owners and the managers of Guild may run bot-wide admin commands like /stench.
+	Owners = flag.String("owners", "", "Comma-separated IDs of users who may run admin commands anywhere")
 
-	default: // "Playing"
-		return fmt.Sprintf(
-			"Dealer Cards: ? + **%v**\r\nPlayer Cards: **%v** = **%d**",
-			g.DealerCards[1:], g.PlayerCards, playerScore,
-		)
+	EvalConcurrency  = flag.Int("eval-concurrency", 4, "How many !eval programs may run at once")
+	EvalSessionScope = flag.String("eval-session-scope", "user", "Whether eval sessions are per \"user\" or per \"channel\"")
+	EvalSessionIdle  = flag.Duration("eval-session-idle", 30*time.Minute, "How long an unused eval session lives")
+)
+
+// isBotAdmin reports whether a user may run bot-wide admin commands: they're
+// one of the owners, or they manage the bot's home guild and ran the command there.
+func isBotAdmin(i *discordgo.InteractionCreate) bool {
+	if slices.Contains(strings.Split(strings.ReplaceAll(*Owners, " ", ""), ","), interactionUserID(i)) {
+		return true
 	}
+	return *Guild != "" && i.GuildID == *Guild && i.Member != nil && i.Member.Permissions&discordgo.PermissionManageServer != 0
 }
 
-// blackjackReset handles the reset-btn interaction. It tears down the old game
-// and starts a fresh one for the user, updating the existing message in place.
-func blackjackReset(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	var userID string
-	if i.User == nil {
-		userID = i.Member.User.ID
-	} else {
-		userID = i.User.ID
+func messageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.Author.ID == s.State.User.ID {
+		return
 	}
-
-	// Remove old game (no-op if missing, e.g. after bot restart)
-	delete(blackjackGames, userID)
-
-	// Deal a fresh game
-	d := newDeck()
-	d.shuffle()
-	dealerCard1, d := d.deal()
-	playerCard1, d := d.deal()
-	dealerCard2, d := d.deal()
-	playerCard2, d := d.deal()
-
-	dealerCards := []string{dealerCard1, dealerCard2}
-	playerCards := []string{playerCard1, playerCard2}
-
-	blackjackGames[userID] = &blackjack{
-		PlayerID:    userID,
-		Deck:        d,
-		DealerCards: dealerCards,
-		PlayerCards: playerCards,
-		Result:      "Playing",
+	// zone := tracy.Zone("messageCreate")
+	// defer zone.End()
+	if strings.HasPrefix(m.Content, "/") {
+		// Ignore slash commands
+		return
 	}
-
-	content := fmt.Sprintf(
-		"Dealer Cards: ? + **%v**\r\nPlayer Cards: **%v**",
-		dealerCards[1:], playerCards,
-	)
-
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseUpdateMessage,
-		Data: &discordgo.InteractionResponseData{
-			Content:    content,
-			Components: gameComponents("Playing"),
-		},
-	})
-	if err != nil {
-		fmt.Println("blackjackReset respond error:", err)
+	fields := strings.Fields(m.Content)
+	if len(fields) > 0 {
+		switch {
+		// The program may follow straight on as a code block, as in !eval```py.
+		case fields[0] == "!eval", strings.HasPrefix(fields[0], "!eval`"):
+			handleEvalMessage(s, m)
+		}
 	}
 }
 
@@ -396,46 +459,56 @@ func handleButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	}
 	data := i.MessageComponentData()
 
-	// Reset is handled entirely by blackjackReset; no game lookup needed here.
-	if data.CustomID == "reset-btn" {
-		blackjackReset(s, i)
+	if strings.HasPrefix(data.CustomID, "wordle-") {
+		handleWordleInteraction(s, i, data.CustomID)
 		return
 	}
-
-	if strings.HasPrefix(data.CustomID, "c4-") {
-		handleConnect4Button(s, i, data.CustomID)
+	if strings.HasPrefix(data.CustomID, "eval-") {
+		handleEvalInteraction(s, i, data.CustomID)
+		return
+	}
+	if strings.HasPrefix(data.CustomID, "evalpage-") {
+		handleEvalPage(s, i, data.CustomID)
+		return
+	}
+	if strings.HasPrefix(data.CustomID, "coinflip-") {
+		handleCoinflipButton(s, i, data.CustomID)
+		return
+	}
+	if strings.HasPrefix(data.CustomID, "trivia-") {
+		handleTriviaAnswer(s, i, data.CustomID)
 		return
 	}
 
-	var userID string
-	if i.User == nil {
-		userID = i.Member.User.ID
-	} else {
-		userID = i.User.ID
+	if host.handleComponent(s, i, data.CustomID) {
+		return
 	}
+}
 
-	g := blackjackGames[userID]
-	var playerScore, dealerScore int
-	switch data.CustomID {
-	case "hit-btn":
-		playerScore, dealerScore = g.hit()
-	case "stay-btn":
-		playerScore, dealerScore = g.stay()
+func handleModal(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionModalSubmit {
+		return
 	}
+	data := i.ModalSubmitData()
 
-	fmt.Println(g.PlayerCards, g.DealerCards)
+	if strings.HasPrefix(data.CustomID, "wordle-") {
+		handleWordleInteraction(s, i, data.CustomID)
+		return
+	}
+	if strings.HasPrefix(data.CustomID, "eval-") {
+		handleEvalInteraction(s, i, data.CustomID)
+		return
+	}
 
-	content := buildBlackJackContent(g, playerScore, dealerScore)
-	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseUpdateMessage,
-		Data: &discordgo.InteractionResponseData{
-			Content:    content,
-			Components: gameComponents(g.Result),
-		},
-	})
+	if host.handleModal(s, i) {
+		return
+	}
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == sandboxArg {
+		sandboxExec(os.Args[2:])
+	}
 	flag.Parse()
 	if *App == "" {
 		content, err := os.ReadFile(".config")
@@ -450,20 +523,30 @@ func main() {
 		*Token = bottoken
 		*Guild = guildid
 	}
-	cmd := exec.Command("escript", "stench", "-s")
-	err := cmd.Start()
-	if err != nil {
-		fmt.Println(err)
-		panic("can't start stench server")
-	}
-	conn := starttcp()
-	fmt.Println(conn, "connection")
+	host.load()
+	loadTournaments()
+	loadWordle()
+	loadMinesweeper()
+	loadTrivia()
+	loadRolls()
+	loadWallets()
+	loadSlots()
+	loadTrainers()
+	loadEvals()
+	evalCfg := loadEvalConfig()
+	stenchProc := newStenchProcess(evalCfg.StenchCmd, evalCfg.StenchAddr, evalCfg.StenchLimits)
 	session, _ := discordgo.New("Bot " + *Token)
 	fmt.Println(session)
-	s := newStenchHandler()
-	session.AddHandler(messageCreate(s))
+	s := newStenchHandler(evalCfg.StenchAddr, stenchProc, *EvalConcurrency)
+	registerEvaluator("stench", s)
+	for name, args := range evalCfg.Runners {
+		registerEvaluator(name, newSubprocessEvaluator(args, evalCfg.Limits, *EvalConcurrency))
+	}
+	go expireEvalSessions()
+	session.AddHandler(messageCreate)
 
 	session.AddHandler(handleButton)
+	session.AddHandler(handleModal)
 	session.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		if i.Type != discordgo.InteractionApplicationCommand {
 			return
@@ -476,7 +559,51 @@ func main() {
 			handleBlackjack(s, i, parseOptions(data.Options))
 		case "connect4":
 			fmt.Println("connect4 command received")
-			handleConnect4(s, i)
+			host.open(s, i, connect4Kind.name)
+		case "tictactoe":
+			handleTictactoe(s, i, parseOptions(data.Options))
+		case "poker":
+			host.open(s, i, pokerKind.name)
+		case "hangman":
+			host.open(s, i, hangmanKind.name)
+		case "wordle":
+			handleWordle(s, i)
+		case "battleship":
+			host.open(s, i, battleshipKind.name)
+		case "minesweeper":
+			handleMinesweeper(s, i, parseOptions(data.Options))
+		case "trivia":
+			handleTrivia(s, i, data.Options)
+		case "checkers":
+			host.challenge(s, i, parseOptions(data.Options), checkersKind.name)
+		case "reversi":
+			host.challenge(s, i, parseOptions(data.Options), reversiKind.name)
+		case "uno":
+			host.open(s, i, unoKind.name)
+		case "roll":
+			handleRoll(s, i, parseOptions(data.Options))
+		case "rolls":
+			handleRolls(s, i, parseOptions(data.Options))
+		case "eval":
+			handleEvalCommand(s, i, parseOptions(data.Options))
+		case "eval-session":
+			handleEvalSession(s, i, data.Options)
+		case "stench":
+			handleStench(s, i, stenchProc, data.Options)
+		case "wallet":
+			handleWallet(s, i)
+		case "daily":
+			handleDaily(s, i)
+		case "slots":
+			handleSlots(s, i, parseOptions(data.Options))
+		case "roulette":
+			handleRoulette(s, i, parseOptions(data.Options))
+		case "coinflip":
+			handleCoinflip(s, i, parseOptions(data.Options))
+		case "chess":
+			handleChess(s, i, parseOptions(data.Options))
+		case "tournament":
+			handleTournament(s, i, data.Options)
 		default:
 			return
 		}
@@ -489,7 +616,7 @@ func main() {
 	// session.AddHandler(handleMessage)
 
 	fmt.Println(session.ApplicationCommands(*App, ""))
-	err = session.Open()
+	err := session.Open()
 	if err != nil {
 		log.Fatalf("could not open session: %s", err)
 	}
@@ -507,10 +634,8 @@ func main() {
 	signal.Notify(sigch, os.Interrupt, syscall.SIGTERM)
 	<-sigch
 
-	if cmd.Process != nil {
-		cmd.Process.Kill()
-	}
-	conn.Close()
+	s.close()
+	stenchProc.stop()
 
 	err = session.Close()
 	if err != nil {
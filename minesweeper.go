@@ -0,0 +1,462 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type minesweeperDifficulty struct {
+	Key    string
+	Name   string
+	Width  int
+	Height int
+	Mines  int
+	// Buttons boards are small enough to play as a grid of buttons; the rest
+	// are drawn as text and played through row and column menus.
+	Buttons bool
+}
+
+var minesweeperDifficulties = []minesweeperDifficulty{
+	{Key: "easy", Name: "Easy", Width: 5, Height: 4, Mines: 4, Buttons: true},
+	{Key: "medium", Name: "Medium", Width: 8, Height: 8, Mines: 10},
+	{Key: "hard", Name: "Hard", Width: 10, Height: 10, Mines: 20},
+}
+
+// minesweeperKinds has a kind per difficulty, so a rematch keeps the same board size.
+var minesweeperKinds = func() []*gameKind {
+	var kinds []*gameKind
+	for _, d := range minesweeperDifficulties {
+		kinds = append(kinds, &gameKind{
+			name:       "ms" + d.Key[:1],
+			title:      "Minesweeper (" + d.Name + ")",
+			minPlayers: 1,
+			maxPlayers: 1,
+			againLabel: "New board",
+			newGame: func(players []string) Game {
+				return newMinesweeper(players[0], d)
+			},
+			decode: decodeGame[minesweeper],
+		})
+	}
+	return kinds
+}()
+
+const minesweeperFile = "minesweeper.json"
+
+var minesweeperNumbers = []string{"⬛", "1️⃣", "2️⃣", "3️⃣", "4️⃣", "5️⃣", "6️⃣", "7️⃣", "8️⃣"}
+
+var minesweeperColumns = []string{"1️⃣", "2️⃣", "3️⃣", "4️⃣", "5️⃣", "6️⃣", "7️⃣", "8️⃣", "9️⃣", "🔟"}
+
+var minesweeperRows = []string{"🇦", "🇧", "🇨", "🇩", "🇪", "🇫", "🇬", "🇭", "🇮", "🇯"}
+
+type minesweeper struct {
+	PlayerID   string
+	Difficulty minesweeperDifficulty
+	// Mines is nil until the first reveal, so the first click is never a mine.
+	Mines    []bool
+	Revealed []bool
+	Flagged  []bool
+	FlagMode bool
+	// Aiming is set once a row has been picked on a text board.
+	Aiming   bool
+	AimRow   int
+	Exploded int
+	Lost     bool
+	Won      bool
+	Started  time.Time
+	Ended    time.Time
+}
+
+func newMinesweeper(playerID string, d minesweeperDifficulty) *minesweeper {
+	n := d.Width * d.Height
+	return &minesweeper{
+		PlayerID:   playerID,
+		Difficulty: d,
+		Revealed:   make([]bool, n),
+		Flagged:    make([]bool, n),
+		Exploded:   -1,
+	}
+}
+
+// neighbours returns the cells touching cell, including diagonals.
+func minesweeperNeighbours(cell, width, height int) []int {
+	r, c := cell/width, cell%width
+	var cells []int
+	for dr := -1; dr <= 1; dr++ {
+		for dc := -1; dc <= 1; dc++ {
+			nr, nc := r+dr, c+dc
+			if (dr != 0 || dc != 0) && nr >= 0 && nr < height && nc >= 0 && nc < width {
+				cells = append(cells, nr*width+nc)
+			}
+		}
+	}
+	return cells
+}
+
+// layMines places the mines away from the first cell and, when there's room, its
+// neighbours too, so the first click always opens up some of the board.
+func layMines(d minesweeperDifficulty, first int) []bool {
+	n := d.Width * d.Height
+	safe := append(minesweeperNeighbours(first, d.Width, d.Height), first)
+	if n-len(safe) < d.Mines {
+		safe = []int{first}
+	}
+	var candidates []int
+	for cell := range n {
+		if !slices.Contains(safe, cell) {
+			candidates = append(candidates, cell)
+		}
+	}
+	rand.Shuffle(len(candidates), func(a, b int) { candidates[a], candidates[b] = candidates[b], candidates[a] })
+	mines := make([]bool, n)
+	for _, cell := range candidates[:d.Mines] {
+		mines[cell] = true
+	}
+	return mines
+}
+
+func minesweeperCount(mines []bool, cell, width, height int) int {
+	n := 0
+	for _, nb := range minesweeperNeighbours(cell, width, height) {
+		if mines[nb] {
+			n++
+		}
+	}
+	return n
+}
+
+// floodReveal reveals cell and, while it keeps finding cells with no mines
+// around them, everything next to those.
+func floodReveal(mines, revealed []bool, cell, width, height int) {
+	queue := []int{cell}
+	revealed[cell] = true
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if minesweeperCount(mines, cur, width, height) > 0 {
+			continue
+		}
+		for _, nb := range minesweeperNeighbours(cur, width, height) {
+			if !revealed[nb] && !mines[nb] {
+				revealed[nb] = true
+				queue = append(queue, nb)
+			}
+		}
+	}
+}
+
+func (g *minesweeper) players() []string { return []string{g.PlayerID} }
+
+func (g *minesweeper) turn() string { return g.PlayerID }
+
+func (g *minesweeper) flagAction() action {
+	label := "🚩 Flag mode: off"
+	style := discordgo.SecondaryButton
+	if g.FlagMode {
+		label, style = "🚩 Flag mode: on", discordgo.PrimaryButton
+	}
+	return action{ID: "flag", Label: label, Style: style, NewRow: true}
+}
+
+func (g *minesweeper) actions() []action {
+	d := g.Difficulty
+	var actions []action
+	if d.Buttons {
+		for cell := range g.Revealed {
+			a := action{ID: "c" + strconv.Itoa(cell), Label: g.cellEmoji(cell), Style: discordgo.SecondaryButton, NewRow: cell%d.Width == 0}
+			if g.Revealed[cell] {
+				a.Disabled = true
+			}
+			actions = append(actions, a)
+		}
+		return append(actions, g.flagAction())
+	}
+
+	// Text boards pick a cell with a row menu and then a column menu, offering
+	// only the cells the current mode can act on.
+	playable := func(cell int) bool {
+		return !g.Revealed[cell] && (g.FlagMode || !g.Flagged[cell])
+	}
+	if !g.Aiming {
+		var rows []discordgo.SelectMenuOption
+		for r := range d.Height {
+			for c := range d.Width {
+				if playable(r*d.Width + c) {
+					rows = append(rows, discordgo.SelectMenuOption{Label: fmt.Sprintf("Row %c", 'A'+r), Value: strconv.Itoa(r)})
+					break
+				}
+			}
+		}
+		if len(rows) > 0 {
+			actions = append(actions, action{ID: "row", Label: "Pick a row", Choices: rows})
+		}
+		return append(actions, g.flagAction())
+	}
+	var cols []discordgo.SelectMenuOption
+	for c := range d.Width {
+		if playable(g.AimRow*d.Width + c) {
+			cols = append(cols, discordgo.SelectMenuOption{Label: fmt.Sprintf("%c%d", 'A'+g.AimRow, c+1), Value: strconv.Itoa(c)})
+		}
+	}
+	verb := "Reveal"
+	if g.FlagMode {
+		verb = "Flag or unflag"
+	}
+	actions = append(actions, action{ID: "col", Label: fmt.Sprintf("%s in row %c", verb, 'A'+g.AimRow), Choices: cols})
+	return append(actions, g.flagAction(), action{ID: "back", Label: "Back", Style: discordgo.SecondaryButton})
+}
+
+func (g *minesweeper) apply(playerID, actionID string) error {
+	verb, arg, _ := strings.Cut(actionID, ":")
+	d := g.Difficulty
+	switch {
+	case verb == "flag":
+		g.FlagMode = !g.FlagMode
+		return nil
+	case verb == "back":
+		g.Aiming = false
+		return nil
+	case verb == "row":
+		r, err := strconv.Atoi(arg)
+		if err != nil || r < 0 || r >= d.Height {
+			return errors.New("Pick a row from the menu.")
+		}
+		g.Aiming, g.AimRow = true, r
+		return nil
+	case verb == "col":
+		c, err := strconv.Atoi(arg)
+		if !g.Aiming || err != nil || c < 0 || c >= d.Width {
+			return errors.New("Pick a row first.")
+		}
+		g.Aiming = false
+		return g.click(g.AimRow*d.Width + c)
+	case strings.HasPrefix(verb, "c"):
+		cell, err := strconv.Atoi(verb[1:])
+		if err != nil || cell < 0 || cell >= len(g.Revealed) {
+			return fmt.Errorf("unknown minesweeper action %q", actionID)
+		}
+		return g.click(cell)
+	}
+	return fmt.Errorf("unknown minesweeper action %q", actionID)
+}
+
+// click flags or reveals a cell depending on the mode.
+func (g *minesweeper) click(cell int) error {
+	d := g.Difficulty
+	if g.Revealed[cell] {
+		return errors.New("That square is already open.")
+	}
+	if g.FlagMode {
+		g.Flagged[cell] = !g.Flagged[cell]
+		return nil
+	}
+	if g.Flagged[cell] {
+		return errors.New("That square is flagged. Switch to flag mode to remove it.")
+	}
+	if g.Mines == nil {
+		g.Mines = layMines(d, cell)
+		g.Started = time.Now()
+	}
+	if g.Mines[cell] {
+		g.Lost, g.Exploded, g.Ended = true, cell, time.Now()
+		return nil
+	}
+	floodReveal(g.Mines, g.Revealed, cell, d.Width, d.Height)
+	cleared := true
+	for k := range g.Revealed {
+		if g.Revealed[k] {
+			g.Flagged[k] = false
+		} else if !g.Mines[k] {
+			cleared = false
+		}
+	}
+	if !cleared {
+		return nil
+	}
+	g.Won, g.Ended = true, time.Now()
+	recordMinesweeperTime(d.Key, g.PlayerID, g.Ended.Sub(g.Started))
+	return nil
+}
+
+// cellEmoji shows a cell as the player sees it; once the game is over every mine is shown.
+func (g *minesweeper) cellEmoji(cell int) string {
+	over := g.Won || g.Lost
+	switch {
+	case cell == g.Exploded:
+		return "💥"
+	case over && g.Flagged[cell] && !g.Mines[cell]:
+		return "❌"
+	case g.Flagged[cell]:
+		return "🚩"
+	case over && g.Mines[cell]:
+		return "💣"
+	case g.Revealed[cell]:
+		return minesweeperNumbers[minesweeperCount(g.Mines, cell, g.Difficulty.Width, g.Difficulty.Height)]
+	}
+	return "⬜"
+}
+
+func (g *minesweeper) grid() string {
+	d := g.Difficulty
+	var sb strings.Builder
+	sb.WriteString("⬛" + strings.Join(minesweeperColumns[:d.Width], "") + "\n")
+	for r := range d.Height {
+		sb.WriteString(minesweeperRows[r])
+		for c := range d.Width {
+			sb.WriteString(g.cellEmoji(r*d.Width + c))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func (g *minesweeper) render() string {
+	d := g.Difficulty
+	flags := 0
+	for _, f := range g.Flagged {
+		if f {
+			flags++
+		}
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<@%s> is playing Minesweeper (%s) · 💣 %d · 🚩 %d", g.PlayerID, d.Name, d.Mines, flags)
+	if !g.Started.IsZero() && g.Ended.IsZero() {
+		fmt.Fprintf(&sb, " · started <t:%d:R>", g.Started.Unix())
+	}
+	sb.WriteString("\n")
+	// Button boards only need the grid drawn once the buttons are gone.
+	if !d.Buttons || g.Won || g.Lost {
+		sb.WriteString(g.grid())
+	}
+	switch {
+	case g.Won:
+		fmt.Fprintf(&sb, "🎉 Cleared in %s!\n", formatMinesweeperTime(g.Ended.Sub(g.Started)))
+		sb.WriteString(minesweeperLeaderboard(d))
+	case g.Lost:
+		sb.WriteString("💥 Boom! Better luck next time.")
+	case g.FlagMode:
+		sb.WriteString("Flag mode is on: clicking a square flags it.")
+	}
+	return sb.String()
+}
+
+func (g *minesweeper) outcome() (bool, string) {
+	if g.Won {
+		return true, g.PlayerID
+	}
+	return g.Lost, ""
+}
+
+// minesweeperTime is a player's best time on one difficulty.
+type minesweeperTime struct {
+	UserID string
+	Time   time.Duration
+	Date   time.Time
+}
+
+var (
+	// minesweeperBest maps difficulty key to each player's best time, fastest first.
+	minesweeperBest   map[string][]minesweeperTime
+	minesweeperBestMu sync.Mutex
+)
+
+func loadMinesweeper() {
+	minesweeperBestMu.Lock()
+	defer minesweeperBestMu.Unlock()
+	minesweeperBest = make(map[string][]minesweeperTime)
+	if err := loadJSON(minesweeperFile, &minesweeperBest); err != nil {
+		fmt.Println("loadMinesweeper error:", err)
+	}
+}
+
+// recordMinesweeperTime keeps a player's time if it beats their previous best.
+func recordMinesweeperTime(difficulty, userID string, t time.Duration) {
+	minesweeperBestMu.Lock()
+	defer minesweeperBestMu.Unlock()
+	times := minesweeperBest[difficulty]
+	k := slices.IndexFunc(times, func(mt minesweeperTime) bool { return mt.UserID == userID })
+	switch {
+	case k < 0:
+		times = append(times, minesweeperTime{UserID: userID, Time: t, Date: time.Now()})
+	case t < times[k].Time:
+		times[k] = minesweeperTime{UserID: userID, Time: t, Date: time.Now()}
+	default:
+		return
+	}
+	sort.SliceStable(times, func(a, b int) bool { return times[a].Time < times[b].Time })
+	minesweeperBest[difficulty] = times
+	if err := saveJSON(minesweeperFile, minesweeperBest); err != nil {
+		fmt.Println("minesweeper save error:", err)
+	}
+}
+
+func formatMinesweeperTime(t time.Duration) string {
+	return fmt.Sprintf("%.1fs", t.Seconds())
+}
+
+func minesweeperLeaderboard(d minesweeperDifficulty) string {
+	minesweeperBestMu.Lock()
+	defer minesweeperBestMu.Unlock()
+	times := minesweeperBest[d.Key]
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Best times (%s)**\n", d.Name)
+	for k, mt := range times[:min(len(times), 5)] {
+		fmt.Fprintf(&sb, "%d. <@%s> %s\n", k+1, mt.UserID, formatMinesweeperTime(mt.Time))
+	}
+	return sb.String()
+}
+
+// spoilerMinesweeper draws a whole board as spoiler tags for people to play on
+// their own. The first click is made for them so they don't start on a mine.
+func spoilerMinesweeper(d minesweeperDifficulty) string {
+	g := newMinesweeper("", d)
+	start := rand.Intn(len(g.Revealed))
+	g.Mines = layMines(d, start)
+	floodReveal(g.Mines, g.Revealed, start, d.Width, d.Height)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Minesweeper (%s)** · 💣 %d\n", d.Name, d.Mines)
+	for r := range d.Height {
+		for c := range d.Width {
+			cell := r*d.Width + c
+			emoji := "💣"
+			if !g.Mines[cell] {
+				emoji = minesweeperNumbers[minesweeperCount(g.Mines, cell, d.Width, d.Height)]
+			}
+			if g.Revealed[cell] {
+				sb.WriteString(emoji)
+			} else {
+				sb.WriteString("||" + emoji + "||")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func handleMinesweeper(s *discordgo.Session, i *discordgo.InteractionCreate, om optionMap) {
+	k := 0
+	if opt, ok := om["difficulty"]; ok {
+		k = slices.IndexFunc(minesweeperDifficulties, func(d minesweeperDifficulty) bool { return d.Key == opt.StringValue() })
+		k = max(k, 0)
+	}
+	if opt, ok := om["spoiler"]; ok && opt.BoolValue() {
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: spoilerMinesweeper(minesweeperDifficulties[k])},
+		})
+		if err != nil {
+			fmt.Println("handleMinesweeper respond error:", err)
+		}
+		return
+	}
+	host.open(s, i, minesweeperKinds[k].name)
+}
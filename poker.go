@@ -0,0 +1,603 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	bj "geofbot/blackjack"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var pokerKind = &gameKind{
+	name:       "poker",
+	title:      "Texas Hold'em",
+	minPlayers: 2,
+	maxPlayers: 9,
+	againLabel: "New table",
+	peekLabel:  "Show my cards",
+	newGame: func(players []string) Game {
+		g := &poker{Button: len(players) - 1}
+		for _, p := range players {
+			g.Seats = append(g.Seats, &pokerSeat{ID: p, Stack: pokerStartingStack})
+		}
+		g.startHand()
+		return g
+	},
+	decode: decodeGame[poker],
+}
+
+const (
+	pokerStartingStack = 1000
+	pokerSmallBlind    = 5
+	pokerBigBlind      = 10
+)
+
+const (
+	preflop = iota
+	flop
+	turnStreet
+	river
+)
+
+var cardRanks = []string{"2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K", "A"}
+
+var cardSuits = []string{"♠", "♥", "♦", "♣"}
+
+// newSuitedDeck returns a 52 card deck where each card is its rank followed by its suit, e.g. "10♥".
+func newSuitedDeck() bj.Deck {
+	d := make(bj.Deck, 0, 52)
+	for _, suit := range cardSuits {
+		for _, rank := range cardRanks {
+			d = append(d, rank+suit)
+		}
+	}
+	return d
+}
+
+type pokerSeat struct {
+	ID    string
+	Stack int
+	Hole  []string
+	// Bet is what the seat has put in during the current betting round, Total during the whole hand.
+	Bet    int
+	Total  int
+	Folded bool
+	AllIn  bool
+	// Acted is set once the seat has acted since the last full raise. Only a
+	// full raise clears it, so a seat that has acted and then faces a short
+	// all-in can call or fold but not raise again.
+	Acted bool
+	// Out marks a seat that wasn't dealt into the hand because it has no chips.
+	Out bool
+}
+
+func (st *pokerSeat) live() bool {
+	return !st.Out && !st.Folded
+}
+
+func (st *pokerSeat) canAct() bool {
+	return st.live() && !st.AllIn
+}
+
+type poker struct {
+	Seats      []*pokerSeat
+	Deck       bj.Deck
+	Board      []string
+	Button     int
+	ToAct      int
+	CurrentBet int
+	MinRaise   int
+	Street     int
+	InHand     bool
+	HandNo     int
+	// Log holds what happened at the end of the last hand.
+	Log []string
+}
+
+func (g *poker) players() []string {
+	ids := make([]string, len(g.Seats))
+	for k, st := range g.Seats {
+		ids[k] = st.ID
+	}
+	return ids
+}
+
+// turn lets anyone deal the next hand between hands.
+func (g *poker) turn() string {
+	if !g.InHand {
+		return ""
+	}
+	return g.Seats[g.ToAct].ID
+}
+
+func (g *poker) actions() []action {
+	if !g.InHand {
+		return []action{{ID: "next", Label: "Deal next hand", Style: discordgo.SuccessButton}}
+	}
+	st := g.Seats[g.ToAct]
+	toCall := g.CurrentBet - st.Bet
+	var actions []action
+	if toCall > 0 {
+		actions = append(actions, action{ID: "fold", Label: "Fold", Style: discordgo.DangerButton})
+		actions = append(actions, action{ID: "call", Label: fmt.Sprintf("Call %d", min(toCall, st.Stack)), Style: discordgo.SuccessButton})
+	} else {
+		actions = append(actions, action{ID: "check", Label: "Check", Style: discordgo.SuccessButton})
+	}
+	if st.Acted {
+		return actions
+	}
+	if minTo := g.CurrentBet + g.MinRaise; st.Stack > toCall && st.Bet+st.Stack > minTo {
+		label := "Raise"
+		if g.CurrentBet == 0 {
+			label = "Bet"
+		}
+		actions = append(actions, action{
+			ID:     "raise",
+			Label:  label,
+			Prompt: fmt.Sprintf("%s to (%d–%d)", label, minTo, st.Bet+st.Stack),
+		})
+	}
+	if st.Stack > toCall {
+		actions = append(actions, action{ID: "allin", Label: fmt.Sprintf("All-in %d", st.Stack), Style: discordgo.DangerButton})
+	}
+	return actions
+}
+
+func (g *poker) apply(playerID, actionID string) error {
+	verb, amount, _ := strings.Cut(actionID, ":")
+	if verb == "next" {
+		if g.InHand {
+			return errors.New("The hand isn't over yet.")
+		}
+		g.startHand()
+		return nil
+	}
+	if !g.InHand {
+		return errors.New("Wait for the next hand to be dealt.")
+	}
+	st := g.Seats[g.ToAct]
+	toCall := g.CurrentBet - st.Bet
+	switch verb {
+	case "fold":
+		st.Folded = true
+	case "check":
+		if toCall > 0 {
+			return fmt.Errorf("You need to call %d or fold.", toCall)
+		}
+	case "call":
+		g.post(st, min(toCall, st.Stack))
+	case "raise", "allin":
+		if st.Acted && st.Stack > toCall {
+			return errors.New("Only a full raise reopens the betting, so you can call or fold.")
+		}
+		if verb == "allin" {
+			g.raiseTo(st, st.Bet+st.Stack)
+			break
+		}
+		to, err := strconv.Atoi(strings.TrimSpace(amount))
+		if err != nil {
+			return errors.New("Enter the total you want to raise to as a number.")
+		}
+		if to > st.Bet+st.Stack {
+			return fmt.Errorf("You only have %d chips.", st.Bet+st.Stack)
+		}
+		if to < g.CurrentBet+g.MinRaise && to < st.Bet+st.Stack {
+			return fmt.Errorf("The minimum raise is to %d.", g.CurrentBet+g.MinRaise)
+		}
+		g.raiseTo(st, to)
+	default:
+		return fmt.Errorf("unknown poker action %q", actionID)
+	}
+	st.Acted = true
+	g.advance()
+	return nil
+}
+
+// post moves chips from a seat's stack into the pot.
+func (g *poker) post(st *pokerSeat, amount int) {
+	amount = min(amount, st.Stack)
+	st.Stack -= amount
+	st.Bet += amount
+	st.Total += amount
+	if st.Stack == 0 {
+		st.AllIn = true
+	}
+}
+
+// raiseTo brings a seat's bet up to a total of to. A full raise reopens the
+// betting for everyone else; a short all-in only has to be called.
+func (g *poker) raiseTo(st *pokerSeat, to int) {
+	g.post(st, to-st.Bet)
+	if st.Bet <= g.CurrentBet {
+		return
+	}
+	if raise := st.Bet - g.CurrentBet; raise >= g.MinRaise {
+		g.MinRaise = raise
+		for _, other := range g.Seats {
+			other.Acted = false
+		}
+	}
+	g.CurrentBet = st.Bet
+}
+
+// next returns the seat after k that satisfies ok, or -1 if there is none.
+func (g *poker) next(k int, ok func(*pokerSeat) bool) int {
+	for step := 1; step <= len(g.Seats); step++ {
+		n := (k + step) % len(g.Seats)
+		if ok(g.Seats[n]) {
+			return n
+		}
+	}
+	return -1
+}
+
+func (g *poker) count(ok func(*pokerSeat) bool) int {
+	n := 0
+	for _, st := range g.Seats {
+		if ok(st) {
+			n++
+		}
+	}
+	return n
+}
+
+func (g *poker) startHand() {
+	for _, st := range g.Seats {
+		*st = pokerSeat{ID: st.ID, Stack: st.Stack, Out: st.Stack == 0}
+	}
+	g.HandNo++
+	g.Board = nil
+	g.Log = nil
+	g.Street = preflop
+	g.InHand = true
+	g.Deck = newSuitedDeck()
+	g.Deck.Shuffle()
+
+	dealt := func(st *pokerSeat) bool { return !st.Out }
+	g.Button = g.next(g.Button, dealt)
+	sb := g.next(g.Button, dealt)
+	// Heads up, the button posts the small blind and acts first before the flop.
+	if g.count(dealt) == 2 {
+		sb = g.Button
+	}
+	bb := g.next(sb, dealt)
+	g.post(g.Seats[sb], pokerSmallBlind)
+	g.post(g.Seats[bb], pokerBigBlind)
+	g.CurrentBet, g.MinRaise = pokerBigBlind, pokerBigBlind
+	for range 2 {
+		for k := range g.Seats {
+			st := g.Seats[(sb+k)%len(g.Seats)]
+			if !st.Out {
+				var card string
+				card, g.Deck = g.Deck.Deal()
+				st.Hole = append(st.Hole, card)
+			}
+		}
+	}
+	g.ToAct = g.next(bb, (*pokerSeat).canAct)
+	if g.ToAct < 0 || g.roundComplete() {
+		g.nextStreet()
+	}
+}
+
+// roundComplete reports whether everyone still able to bet has matched the
+// current bet and had a chance to act on it.
+func (g *poker) roundComplete() bool {
+	if g.count((*pokerSeat).canAct) <= 1 {
+		for _, st := range g.Seats {
+			if st.canAct() && st.Bet < g.CurrentBet {
+				return false
+			}
+		}
+		return true
+	}
+	for _, st := range g.Seats {
+		if st.canAct() && (!st.Acted || st.Bet != g.CurrentBet) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *poker) advance() {
+	if g.count((*pokerSeat).live) == 1 {
+		winner := g.Seats[g.next(-1, (*pokerSeat).live)]
+		pot := g.pot()
+		winner.Stack += pot
+		g.Log = []string{fmt.Sprintf("<@%s> wins %d chips uncontested.", winner.ID, pot)}
+		g.InHand = false
+		return
+	}
+	if g.roundComplete() {
+		g.nextStreet()
+		return
+	}
+	g.ToAct = g.next(g.ToAct, (*pokerSeat).canAct)
+}
+
+// nextStreet deals the next board cards. When fewer than two players can
+// still bet, the board is run out straight to the showdown.
+func (g *poker) nextStreet() {
+	for {
+		for _, st := range g.Seats {
+			st.Bet, st.Acted = 0, false
+		}
+		g.CurrentBet, g.MinRaise = 0, pokerBigBlind
+		if g.Street == river {
+			g.showdown()
+			return
+		}
+		n := 1
+		if g.Street == preflop {
+			n = 3
+		}
+		for range n {
+			var card string
+			card, g.Deck = g.Deck.Deal()
+			g.Board = append(g.Board, card)
+		}
+		g.Street++
+		if g.count((*pokerSeat).canAct) >= 2 {
+			g.ToAct = g.next(g.Button, (*pokerSeat).canAct)
+			return
+		}
+	}
+}
+
+func (g *poker) pot() int {
+	pot := 0
+	for _, st := range g.Seats {
+		pot += st.Total
+	}
+	return pot
+}
+
+// showdown splits the pot into a main pot and side pots by how much each live
+// player put in, and awards each to the best hand eligible for it.
+func (g *poker) showdown() {
+	g.InHand = false
+	g.Log = nil
+	scores := make(map[*pokerSeat]int)
+	var levels []int
+	for _, st := range g.Seats {
+		if !st.live() {
+			continue
+		}
+		score, name := bestHand(append(slices.Clone(st.Hole), g.Board...))
+		scores[st] = score
+		levels = append(levels, st.Total)
+		g.Log = append(g.Log, fmt.Sprintf("<@%s> shows %s — %s", st.ID, strings.Join(st.Hole, " "), name))
+	}
+	slices.Sort(levels)
+	levels = slices.Compact(levels)
+
+	prev := 0
+	for n, level := range levels {
+		last := n == len(levels)-1
+		pot := 0
+		for _, st := range g.Seats {
+			// Chips folded players put in above the top level still belong in the last pot.
+			if last {
+				pot += st.Total - min(st.Total, prev)
+			} else {
+				pot += min(st.Total, level) - min(st.Total, prev)
+			}
+		}
+		prev = level
+		if pot == 0 {
+			continue
+		}
+		best := -1
+		var winners []*pokerSeat
+		// Walk from the seat after the button so any odd chip goes to the first winner in that order.
+		for step := 1; step <= len(g.Seats); step++ {
+			st := g.Seats[(g.Button+step)%len(g.Seats)]
+			score, ok := scores[st]
+			if !ok || st.Total < level {
+				continue
+			}
+			if score > best {
+				best, winners = score, []*pokerSeat{st}
+			} else if score == best {
+				winners = append(winners, st)
+			}
+		}
+		share := pot / len(winners)
+		for k, st := range winners {
+			st.Stack += share
+			if k == 0 {
+				st.Stack += pot % len(winners)
+			}
+		}
+		potName := "the main pot"
+		if n > 0 {
+			potName = fmt.Sprintf("side pot %d", n)
+		}
+		mentions := make([]string, len(winners))
+		for k, st := range winners {
+			mentions[k] = fmt.Sprintf("<@%s>", st.ID)
+		}
+		if len(winners) == 1 {
+			g.Log = append(g.Log, fmt.Sprintf("%s wins %s (%d chips).", mentions[0], potName, pot))
+		} else {
+			g.Log = append(g.Log, fmt.Sprintf("%s split %s (%d chips).", strings.Join(mentions, ", "), potName, pot))
+		}
+	}
+}
+
+func (g *poker) outcome() (bool, string) {
+	if g.InHand {
+		return false, ""
+	}
+	withChips := func(st *pokerSeat) bool { return st.Stack > 0 }
+	if g.count(withChips) > 1 {
+		return false, ""
+	}
+	return true, g.Seats[g.next(-1, withChips)].ID
+}
+
+func (g *poker) render() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "♠️ **Texas Hold'em** — hand #%d · blinds %d/%d\n", g.HandNo, pokerSmallBlind, pokerBigBlind)
+	board := "—"
+	if len(g.Board) > 0 {
+		board = strings.Join(g.Board, " ")
+	}
+	fmt.Fprintf(&sb, "Board: **%s** · Pot: **%d**\n\n", board, g.pot())
+	for k, st := range g.Seats {
+		marker := "▫️"
+		if g.InHand && k == g.ToAct {
+			marker = "▶️"
+		}
+		fmt.Fprintf(&sb, "%s <@%s> · %d chips", marker, st.ID, st.Stack)
+		if k == g.Button {
+			sb.WriteString(" · 🔘 dealer")
+		}
+		switch {
+		case st.Out:
+			sb.WriteString(" · out")
+		case st.Folded:
+			sb.WriteString(" · folded")
+		case st.AllIn:
+			sb.WriteString(" · all-in")
+		}
+		if st.Bet > 0 {
+			fmt.Fprintf(&sb, " · bet %d", st.Bet)
+		}
+		sb.WriteString("\n")
+	}
+	if len(g.Log) > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(strings.Join(g.Log, "\n"))
+		sb.WriteString("\n")
+	}
+	if over, winner := g.outcome(); over {
+		fmt.Fprintf(&sb, "\n🏆 <@%s> takes every chip at the table!", winner)
+		return sb.String()
+	}
+	if g.InHand {
+		st := g.Seats[g.ToAct]
+		if toCall := g.CurrentBet - st.Bet; toCall > 0 {
+			fmt.Fprintf(&sb, "\n<@%s> to act — %d to call.", st.ID, min(toCall, st.Stack))
+		} else {
+			fmt.Fprintf(&sb, "\n<@%s> to act.", st.ID)
+		}
+	} else {
+		sb.WriteString("\nClick Deal to start the next hand.")
+	}
+	return sb.String()
+}
+
+func (g *poker) privateView(playerID string) (string, []action) {
+	for _, st := range g.Seats {
+		if st.ID != playerID {
+			continue
+		}
+		if len(st.Hole) == 0 {
+			return "You weren't dealt into this hand.", nil
+		}
+		view := "Your cards: **" + strings.Join(st.Hole, " ") + "**"
+		if len(g.Board) >= 3 {
+			_, name := bestHand(append(slices.Clone(st.Hole), g.Board...))
+			view += "\nBest hand: " + name
+		}
+		return view, nil
+	}
+	return "You're not playing in this game.", nil
+}
+
+var handNames = []string{
+	"High card", "Pair", "Two pair", "Three of a kind", "Straight",
+	"Flush", "Full house", "Four of a kind", "Straight flush",
+}
+
+// cardRankSuit splits a suited card into its rank (2 to 14, aces high) and suit.
+func cardRankSuit(card string) (int, string) {
+	_, size := utf8.DecodeLastRuneInString(card)
+	rank, suit := card[:len(card)-size], card[len(card)-size:]
+	return slices.Index(cardRanks, rank) + 2, suit
+}
+
+// bestHand scores the best five card hand that can be made from cards.
+// Higher scores beat lower ones and equal scores split the pot.
+func bestHand(cards []string) (int, string) {
+	best := -1
+	var five [5]string
+	var choose func(start, n int)
+	choose = func(start, n int) {
+		if n == 5 {
+			best = max(best, scoreFive(five))
+			return
+		}
+		for k := start; k < len(cards); k++ {
+			five[n] = cards[k]
+			choose(k+1, n+1)
+		}
+	}
+	choose(0, 0)
+	// The category is the leading base-15 digit of the score.
+	return best, handNames[best/(15*15*15*15*15)]
+}
+
+func scoreFive(cards [5]string) int {
+	var counts [15]int
+	flush := true
+	_, firstSuit := cardRankSuit(cards[0])
+	for _, c := range cards {
+		rank, suit := cardRankSuit(c)
+		counts[rank]++
+		flush = flush && suit == firstSuit
+	}
+	// Order ranks by how many of each there are, then by rank.
+	var order []int
+	for n := 4; n >= 1; n-- {
+		for rank := 14; rank >= 2; rank-- {
+			if counts[rank] == n {
+				order = append(order, rank)
+			}
+		}
+	}
+	straightHigh := 0
+	if len(order) == 5 {
+		if order[0]-order[4] == 4 {
+			straightHigh = order[0]
+		} else if order[0] == 14 && order[1] == 5 {
+			straightHigh = 5
+		}
+	}
+	var category int
+	switch {
+	case straightHigh > 0 && flush:
+		category = 8
+	case counts[order[0]] == 4:
+		category = 7
+	case counts[order[0]] == 3 && counts[order[1]] == 2:
+		category = 6
+	case flush:
+		category = 5
+	case straightHigh > 0:
+		category = 4
+	case counts[order[0]] == 3:
+		category = 3
+	case counts[order[0]] == 2 && counts[order[1]] == 2:
+		category = 2
+	case counts[order[0]] == 2:
+		category = 1
+	}
+	if straightHigh > 0 {
+		order = []int{straightHigh}
+	}
+	score := category
+	for k := range 5 {
+		score *= 15
+		if k < len(order) {
+			score += order[k]
+		}
+	}
+	return score
+}
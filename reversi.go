@@ -0,0 +1,230 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var reversiKind = &gameKind{
+	name:       "rv",
+	title:      "Reversi",
+	minPlayers: 2,
+	maxPlayers: 2,
+	againLabel: "Rematch",
+	newGame: func(players []string) Game {
+		g := &reversi{BlackID: players[0], WhiteID: players[1], BlackMove: true}
+		g.Board[parseSquare("d4")], g.Board[parseSquare("e5")] = -1, -1
+		g.Board[parseSquare("e4")], g.Board[parseSquare("d5")] = 1, 1
+		return g
+	},
+	decode: decodeGame[reversi],
+}
+
+var reversiDirs = [8][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+
+// reversiBoard holds 1 for black discs and -1 for white.
+type reversiBoard [64]int8
+
+func reversiColor(black bool) int8 {
+	if black {
+		return 1
+	}
+	return -1
+}
+
+// flips returns the discs a move at sq would turn over.
+func (b *reversiBoard) flips(sq int, black bool) []int {
+	if b[sq] != 0 {
+		return nil
+	}
+	me := reversiColor(black)
+	var flipped []int
+	for _, d := range reversiDirs {
+		var line []int
+		f, r := sq%8+d[0], sq/8+d[1]
+		for onBoard(f, r) && b[r*8+f] == -me {
+			line = append(line, r*8+f)
+			f, r = f+d[0], r+d[1]
+		}
+		if len(line) > 0 && onBoard(f, r) && b[r*8+f] == me {
+			flipped = append(flipped, line...)
+		}
+	}
+	return flipped
+}
+
+func (b *reversiBoard) legalMoves(black bool) []int {
+	var moves []int
+	for sq := range b {
+		if len(b.flips(sq, black)) > 0 {
+			moves = append(moves, sq)
+		}
+	}
+	return moves
+}
+
+func (b *reversiBoard) count() (black, white int) {
+	for _, d := range b {
+		switch d {
+		case 1:
+			black++
+		case -1:
+			white++
+		}
+	}
+	return black, white
+}
+
+type reversi struct {
+	BlackID, WhiteID string
+	Board            reversiBoard
+	BlackMove        bool
+	Last             string
+	Over             bool
+	Resigned         string
+}
+
+func (g *reversi) players() []string { return []string{g.BlackID, g.WhiteID} }
+
+func (g *reversi) turn() string {
+	if g.BlackMove {
+		return g.BlackID
+	}
+	return g.WhiteID
+}
+
+// actions offers the legal moves in menus of up to 25, or a pass when there are none.
+func (g *reversi) actions() []action {
+	legal := g.Board.legalMoves(g.BlackMove)
+	if len(legal) == 0 {
+		return []action{
+			{ID: "pass", Label: "Pass (no moves)", Style: discordgo.PrimaryButton},
+			{ID: "resign", Label: "Resign", Style: discordgo.DangerButton, AnyPlayer: true},
+		}
+	}
+	var actions []action
+	for start := 0; start < len(legal); start += 25 {
+		var opts []discordgo.SelectMenuOption
+		for _, sq := range legal[start:min(start+25, len(legal))] {
+			opts = append(opts, discordgo.SelectMenuOption{
+				Label: fmt.Sprintf("%s (flips %d)", squareName(sq), len(g.Board.flips(sq, g.BlackMove))),
+				Value: squareName(sq),
+			})
+		}
+		actions = append(actions, action{ID: fmt.Sprintf("m%d", start/25), Label: "Place a disc", Choices: opts})
+	}
+	return append(actions, action{ID: "resign", Label: "Resign", Style: discordgo.DangerButton, AnyPlayer: true})
+}
+
+func (g *reversi) apply(playerID, actionID string) error {
+	verb, value, _ := strings.Cut(actionID, ":")
+	switch {
+	case verb == "pass":
+		if len(g.Board.legalMoves(g.BlackMove)) > 0 {
+			return errors.New("You can only pass when you have no legal moves.")
+		}
+		g.Last = "pass"
+		g.BlackMove = !g.BlackMove
+		return nil
+	case verb == "resign":
+		g.Over, g.Resigned = true, playerID
+		return nil
+	case strings.HasPrefix(verb, "m"):
+		sq := parseSquare(value)
+		flipped := []int(nil)
+		if sq >= 0 {
+			flipped = g.Board.flips(sq, g.BlackMove)
+		}
+		if len(flipped) == 0 {
+			return errors.New("That isn't a legal move.")
+		}
+		me := reversiColor(g.BlackMove)
+		g.Board[sq] = me
+		for _, f := range flipped {
+			g.Board[f] = me
+		}
+		g.Last = squareName(sq)
+		g.BlackMove = !g.BlackMove
+		if len(g.Board.legalMoves(true)) == 0 && len(g.Board.legalMoves(false)) == 0 {
+			g.Over = true
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown reversi action %q", actionID)
+}
+
+func (g *reversi) outcome() (bool, string) {
+	if !g.Over {
+		return false, ""
+	}
+	if g.Resigned != "" {
+		if g.Resigned == g.BlackID {
+			return true, g.WhiteID
+		}
+		return true, g.BlackID
+	}
+	black, white := g.Board.count()
+	switch {
+	case black > white:
+		return true, g.BlackID
+	case white > black:
+		return true, g.WhiteID
+	}
+	return true, ""
+}
+
+// render draws the board with the current player's legal moves marked.
+func (g *reversi) render() string {
+	var legal []int
+	if !g.Over {
+		legal = g.Board.legalMoves(g.BlackMove)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "⚫ <@%s> vs ⚪ <@%s>\n```\n", g.BlackID, g.WhiteID)
+	for r := 7; r >= 0; r-- {
+		fmt.Fprintf(&sb, "%d ", r+1)
+		for f := range 8 {
+			sq := r*8 + f
+			switch {
+			case g.Board[sq] == 1:
+				sb.WriteString("X")
+			case g.Board[sq] == -1:
+				sb.WriteString("O")
+			case slices.Contains(legal, sq):
+				sb.WriteString("*")
+			default:
+				sb.WriteString("·")
+			}
+			sb.WriteString(" ")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("  a b c d e f g h\n```")
+	black, white := g.Board.count()
+	fmt.Fprintf(&sb, "\nX (black) %d · O (white) %d", black, white)
+	if g.Last != "" {
+		fmt.Fprintf(&sb, " · Last move: **%s**", g.Last)
+	}
+	over, winner := g.outcome()
+	switch {
+	case over && g.Resigned != "":
+		fmt.Fprintf(&sb, "\n<@%s> resigned. 🎉 <@%s> wins!", g.Resigned, winner)
+	case over && winner != "":
+		fmt.Fprintf(&sb, "\n🎉 <@%s> wins!", winner)
+	case over:
+		sb.WriteString("\nIt's a draw!")
+	case len(legal) == 0:
+		fmt.Fprintf(&sb, "\n<@%s> has no moves and must pass.", g.turn())
+	default:
+		side := "X (black)"
+		if !g.BlackMove {
+			side = "O (white)"
+		}
+		fmt.Fprintf(&sb, "\n%s to move: <@%s> · `*` marks legal moves", side, g.turn())
+	}
+	return sb.String()
+}
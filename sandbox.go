@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"geofbot/stench"
+)
+
+// evalLimits are the resources a subprocess evaluator's program may use. A
+// zero limit is no limit. CPU time and memory are only enforced on Linux.
+type evalLimits struct {
+	CPU    time.Duration
+	Wall   time.Duration
+	Memory int64
+	Output int
+	// Cgroup, if set, is a cgroup v2 directory the bot may create child groups
+	// in. Memory is then limited by the cgroup, which also tells us when it was
+	// the reason a program died; otherwise it's limited with RLIMIT_AS.
+	Cgroup string
+}
+
+var defaultEvalLimits = evalLimits{
+	CPU:    5 * time.Second,
+	Wall:   evalTimeout,
+	Memory: 256 << 20,
+	Output: 64 << 10,
+}
+
+// defaultStenchLimits bound the long-running stench server. Its CPU limit is
+// a budget for its whole life, and its memory is only limited in a cgroup,
+// since the BEAM reserves more address space up front than RLIMIT_AS could
+// sensibly allow. Hitting either gets the server restarted.
+var defaultStenchLimits = evalLimits{
+	CPU:    time.Hour,
+	Wall:   evalTimeout,
+	Memory: 1 << 30,
+}
+
+// setLimit applies a limit.<name> line from the config file.
+func (l *evalLimits) setLimit(name, value string) error {
+	var err error
+	switch name {
+	case "cpu":
+		l.CPU, err = time.ParseDuration(value)
+	case "wall":
+		l.Wall, err = time.ParseDuration(value)
+	case "memory":
+		l.Memory, err = parseSize(value)
+	case "output":
+		var n int64
+		n, err = parseSize(value)
+		l.Output = int(n)
+	case "cgroup":
+		l.Cgroup = value
+	default:
+		err = fmt.Errorf("unknown limit %q", name)
+	}
+	return err
+}
+
+// parseSize reads a byte count with an optional K, M or G suffix.
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	shift := 0
+	switch {
+	case strings.HasSuffix(s, "K"):
+		shift = 10
+	case strings.HasSuffix(s, "M"):
+		shift = 20
+	case strings.HasSuffix(s, "G"):
+		shift = 30
+	}
+	if shift > 0 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("bad size %q", s)
+	}
+	return n << shift, nil
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30 && n%(1<<30) == 0:
+		return fmt.Sprintf("%d GiB", n>>30)
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%d MiB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%d KiB", n>>10)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// cappedBuffer collects a program's output, calling over once the program
+// has written more than the limit between its stdout and stderr. The buffer
+// isn't embedded, so io.Copy can't go around Write through its ReadFrom.
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	mu    *sync.Mutex
+	left  *int
+	over  func()
+	limit bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.limit {
+		return b.buf.Write(p)
+	}
+	if len(p) > *b.left {
+		b.buf.Write(p[:*b.left])
+		*b.left = 0
+		b.over()
+		return 0, errors.New("output limit exceeded")
+	}
+	*b.left -= len(p)
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) String() string { return b.buf.String() }
+
+// runSandboxed runs a program under the limits, piping code to its stdin. If
+// a limit stops it, the response says which and holds the output up to then.
+func runSandboxed(args []string, code string, l evalLimits) (stench.Response, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if l.Wall > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), l.Wall)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var mu sync.Mutex
+	left := l.Output
+	var outputHit bool
+	over := func() {
+		outputHit = true
+		cancel()
+	}
+	stdout := &cappedBuffer{mu: &mu, left: &left, over: over, limit: l.Output > 0}
+	stderr := &cappedBuffer{mu: &mu, left: &left, over: over, limit: l.Output > 0}
+	cmd.Stdin = strings.NewReader(code)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	// Don't wait on children of a killed program that still hold its output open.
+	cmd.WaitDelay = 100 * time.Millisecond
+	finish, err := sandboxCommand(cmd, l)
+	if err != nil {
+		fmt.Println("sandbox setup error:", err)
+		return stench.Response{}, errors.New("Couldn't set up a sandbox for the program.")
+	}
+	err = cmd.Run()
+	oom := finish()
+
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) && ctx.Err() == nil {
+		fmt.Println("subprocess eval error:", err)
+		return stench.Response{}, fmt.Errorf("Couldn't start `%s`.", args[0])
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	resp := stench.Response{Stdout: stdout.String(), Stderr: stderr.String(), Exit: cmd.ProcessState.ExitCode()}
+	switch {
+	case outputHit:
+		resp.Limit = fmt.Sprintf("output (%s)", formatSize(int64(l.Output)))
+	case ctx.Err() == context.DeadlineExceeded:
+		resp.Limit = fmt.Sprintf("wall clock (%s)", l.Wall)
+	case l.CPU > 0 && cpuLimitHit(cmd.ProcessState, l.CPU):
+		resp.Limit = fmt.Sprintf("CPU time (%s)", l.CPU)
+	case l.Memory > 0 && (oom || resp.Exit != 0 && l.Cgroup == "" && looksOutOfMemory(resp.Stderr)):
+		resp.Limit = fmt.Sprintf("memory (%s)", formatSize(l.Memory))
+	}
+	return resp, nil
+}
+
+// looksOutOfMemory guesses from a program's errors whether it ran out of
+// memory, for when there's no cgroup to say so.
+func looksOutOfMemory(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, s := range []string{"out of memory", "memoryerror", "cannot allocate memory", "bad_alloc"} {
+		if strings.Contains(stderr, s) {
+			return true
+		}
+	}
+	return false
+}
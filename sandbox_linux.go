@@ -0,0 +1,143 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// sandboxArg re-runs the bot as a small launcher that sets a program's
+// rlimits on itself and then execs it, so the limits are in place before the
+// program's first instruction.
+const sandboxArg = "__sandbox"
+
+// sandboxCommand makes cmd run under the limits, in its own process group so
+// a kill takes any children with it. finish must be called once cmd has
+// exited; it cleans up and reports whether the cgroup killed the program for
+// using too much memory.
+func sandboxCommand(cmd *exec.Cmd, l evalLimits) (finish func() (oom bool), err error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	finish = func() bool { return false }
+
+	addressSpace := l.Memory
+	if l.Cgroup != "" && l.Memory > 0 {
+		dir, fd, err := newEvalCgroup(l.Cgroup, l.Memory)
+		if err != nil {
+			fmt.Println("eval cgroup error, falling back to rlimits:", err)
+		} else {
+			addressSpace = 0
+			cmd.SysProcAttr.UseCgroupFD, cmd.SysProcAttr.CgroupFD = true, fd
+			finish = func() bool {
+				syscall.Close(fd)
+				events, _ := os.ReadFile(filepath.Join(dir, "memory.events"))
+				os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0)
+				os.Remove(dir)
+				return cgroupOOMKilled(string(events))
+			}
+		}
+	}
+
+	var cpu int64
+	if l.CPU > 0 {
+		// RLIMIT_CPU counts whole seconds; round up so short limits still allow some time.
+		cpu = int64((l.CPU + time.Second - 1) / time.Second)
+	}
+	cmd.Args = append([]string{self, sandboxArg, strconv.FormatInt(cpu, 10), strconv.FormatInt(addressSpace, 10), "--"}, cmd.Args...)
+	cmd.Path = self
+	return finish, nil
+}
+
+// newEvalCgroup makes a child cgroup with a memory limit and no swap and
+// returns it opened, for starting a process straight into it.
+func newEvalCgroup(parent string, memory int64) (string, int, error) {
+	dir := filepath.Join(parent, "eval-"+newGameID())
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return "", 0, err
+	}
+	settings := map[string]string{
+		"memory.max":      strconv.FormatInt(memory, 10),
+		"memory.swap.max": "0",
+	}
+	for name, value := range settings {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0); err != nil {
+			os.Remove(dir)
+			return "", 0, err
+		}
+	}
+	fd, err := syscall.Open(dir, syscall.O_DIRECTORY|syscall.O_RDONLY, 0)
+	if err != nil {
+		os.Remove(dir)
+		return "", 0, err
+	}
+	return dir, fd, nil
+}
+
+func cgroupOOMKilled(events string) bool {
+	for _, line := range strings.Split(events, "\n") {
+		if n, ok := strings.CutPrefix(line, "oom_kill "); ok && n != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// cpuLimitHit reports whether the kernel stopped a program for using up its
+// CPU time: SIGXCPU at the soft limit, SIGKILL at the hard one.
+func cpuLimitHit(state *os.ProcessState, limit time.Duration) bool {
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return false
+	}
+	used := state.UserTime() + state.SystemTime()
+	return ws.Signal() == syscall.SIGXCPU || ws.Signal() == syscall.SIGKILL && used >= limit
+}
+
+// sandboxExec is the launcher sandboxCommand starts: it applies the limits
+// given on its command line to itself and execs the program. It never returns.
+func sandboxExec(args []string) {
+	fail := func(err error) {
+		fmt.Fprintln(os.Stderr, "sandbox:", err)
+		os.Exit(126)
+	}
+	if len(args) < 4 || args[2] != "--" {
+		fail(fmt.Errorf("usage: %s cpu-seconds address-space -- program [args]", sandboxArg))
+	}
+	cpu, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		fail(err)
+	}
+	addressSpace, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		fail(err)
+	}
+	if cpu > 0 {
+		if err := syscall.Setrlimit(syscall.RLIMIT_CPU, &syscall.Rlimit{Cur: cpu, Max: cpu + 1}); err != nil {
+			fail(err)
+		}
+	}
+	if addressSpace > 0 {
+		if err := syscall.Setrlimit(syscall.RLIMIT_AS, &syscall.Rlimit{Cur: addressSpace, Max: addressSpace}); err != nil {
+			fail(err)
+		}
+	}
+	program := args[3:]
+	path, err := exec.LookPath(program[0])
+	if err != nil {
+		fail(err)
+	}
+	fail(syscall.Exec(path, program, os.Environ()))
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const sandboxArg = "__sandbox"
+
+// sandboxCommand leaves cmd as it is: CPU time and memory limits need Linux.
+// The wall clock and output limits still apply.
+func sandboxCommand(cmd *exec.Cmd, l evalLimits) (finish func() (oom bool), err error) {
+	return func() bool { return false }, nil
+}
+
+func cpuLimitHit(state *os.ProcessState, limit time.Duration) bool { return false }
+
+func sandboxExec(args []string) {
+	fmt.Fprintln(os.Stderr, "sandbox: only supported on Linux")
+	os.Exit(126)
+}
@@ -1,46 +1,211 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"geofbot/stench"
+)
+
+const (
+	stenchAddr       = ":4040"
+	stenchDialWait   = 2 * time.Second
+	stenchMinBackoff = 250 * time.Millisecond
+	stenchMaxBackoff = 30 * time.Second
 )
 
+var errStenchUnavailable = errors.New("The stench interpreter is unavailable right now. Try again in a bit.")
+
+// stenchHandler talks to the stench interpreter over a pool of TCP
+// connections, so several programs run at once and the rest queue. It
+// reconnects on demand, backing off while the interpreter is down so requests
+// fail fast instead of piling up, and only sends programs to it while proc
+// says it's healthy.
 type stenchHandler struct {
+	evalQueue
+	addr   string
+	proc   *stenchProcess
+	nextID atomic.Uint64
+
+	mu   sync.Mutex
+	idle []*stenchConn
+	// backoff is how long to wait after the next failed dial; retryAt is when
+	// dialing may be tried again.
+	backoff time.Duration
+	retryAt time.Time
+}
+
+// stenchConn is one connection to the interpreter, used by one evaluation at a time.
+type stenchConn struct {
 	conn net.Conn
+	dec  *json.Decoder
+	// generation is the interpreter process the connection is to.
+	generation int
+}
+
+func newStenchConn(conn net.Conn, generation int) *stenchConn {
+	return &stenchConn{conn: conn, dec: json.NewDecoder(bufio.NewReader(conn)), generation: generation}
+}
+
+// newStenchHandler returns a handler for the interpreter proc runs at addr,
+// running up to concurrency programs at once.
+func newStenchHandler(addr string, proc *stenchProcess, concurrency int) *stenchHandler {
+	return &stenchHandler{evalQueue: newEvalQueue(concurrency), addr: addr, proc: proc}
 }
 
-func starttcp() net.Conn {
-	conn, err := net.Dial("tcp", ":4040")
-	i := 0
-	for err != nil && i < 100 {
-		conn, err = net.Dial("tcp", ":4040")
+// connect returns an idle connection, dialing a new one if there isn't one
+// and the backoff has passed. Connections to an interpreter that has since
+// been restarted are dropped.
+func (s *stenchHandler) connect() (*stenchConn, error) {
+	generation, ok := s.proc.ready()
+	if !ok {
+		return nil, errStenchUnavailable
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for n := len(s.idle); n > 0; n = len(s.idle) {
+		c := s.idle[n-1]
+		s.idle = s.idle[:n-1]
+		if c.generation == generation {
+			return c, nil
+		}
+		c.conn.Close()
 	}
+	if time.Now().Before(s.retryAt) {
+		return nil, errStenchUnavailable
+	}
+	conn, err := net.DialTimeout("tcp", s.addr, stenchDialWait)
 	if err != nil {
-		return nil
+		fmt.Println("stench dial error:", err)
+		s.backoff = min(max(2*s.backoff, stenchMinBackoff), stenchMaxBackoff)
+		s.retryAt = time.Now().Add(s.backoff)
+		return nil, errStenchUnavailable
 	}
-	return conn
+	s.backoff = 0
+	return newStenchConn(conn, generation), nil
 }
 
-func newStenchHandler() *stenchHandler {
-	return &stenchHandler{
-		conn: starttcp(),
-	}
+// put returns a healthy connection to the pool.
+func (s *stenchHandler) put(c *stenchConn) {
+	c.conn.SetDeadline(time.Time{})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.idle = append(s.idle, c)
+}
+
+func (s *stenchHandler) eval(session, code string, queued func(position int)) (stench.Response, error) {
+	return s.send(stench.Request{Session: session, Code: code}, queued)
 }
 
-func (s *stenchHandler) eval(input string) string {
+func (s *stenchHandler) reset(session string) error {
+	_, err := s.send(stench.Request{Session: session, Reset: true}, nil)
+	return err
+}
+
+func (s *stenchHandler) send(req stench.Request, queued func(position int)) (stench.Response, error) {
 	// zone := tracy.Zone("stench.eval")
 	// defer zone.End()
-	_, err := s.conn.Write([]byte(input + "\n"))
-	fmt.Println("written")
-	if err != nil {
-		panic(err)
+	s.acquire(queued)
+	defer s.release()
+	// A connection the interpreter closed while idle only shows up on use, so a
+	// failed write, or a connection closed before replying, gets one retry on a
+	// fresh connection.
+	for attempt := 0; ; attempt++ {
+		c, err := s.connect()
+		if err != nil {
+			return stench.Response{}, err
+		}
+		req.ID = s.nextID.Add(1)
+		if wall := s.proc.limits.Wall; wall > 0 {
+			c.conn.SetDeadline(time.Now().Add(wall))
+		}
+		if err = json.NewEncoder(c.conn).Encode(req); err != nil {
+			fmt.Println("stench write error:", err)
+			c.conn.Close()
+			if attempt == 0 {
+				continue
+			}
+			return stench.Response{}, errStenchUnavailable
+		}
+		resp, err := c.await(req.ID)
+		if err != nil {
+			fmt.Println("stench read error:", err)
+			c.conn.Close()
+			// A program that runs too long may never stop by itself, so the
+			// interpreter is restarted, taking every session with it.
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				limit := fmt.Sprintf("wall clock (%s)", s.proc.limits.Wall)
+				s.proc.restart(c.generation, "an evaluation hit the "+limit+" limit")
+				return stench.Response{ID: req.ID, Exit: -1, Limit: limit}, nil
+			}
+			// The interpreter dropping the connection may mean the program
+			// used up its memory or CPU time.
+			if limit := s.proc.limitHit(c.generation); limit != "" {
+				return stench.Response{ID: req.ID, Exit: -1, Limit: limit}, nil
+			}
+			if errors.Is(err, io.EOF) && attempt == 0 {
+				continue
+			}
+			return stench.Response{}, errStenchUnavailable
+		}
+		s.put(c)
+		return resp, nil
 	}
-	buffer := make([]byte, 1028)
-	n, err := s.conn.Read(buffer)
-	fmt.Println("done reading")
-	fmt.Println(string(buffer))
-	if err != nil {
-		panic(err)
+}
+
+// await reads replies until the one for request id, skipping stale replies
+// to requests that were given up on.
+func (c *stenchConn) await(id uint64) (stench.Response, error) {
+	for {
+		var resp stench.Response
+		if err := c.dec.Decode(&resp); err != nil {
+			return resp, err
+		}
+		if resp.ID == id {
+			return resp, nil
+		}
+		fmt.Println("stench: skipping stale reply", resp.ID)
+	}
+}
+
+// formatEval lays out a reply the way !eval shows it: what the program
+// printed, then its errors, then its value.
+func formatEval(resp stench.Response) string {
+	var parts []string
+	for _, part := range []string{resp.Stdout, resp.Stderr} {
+		if part != "" {
+			parts = append(parts, strings.TrimSuffix(part, "\n"))
+		}
+	}
+	if resp.Result != "" {
+		parts = append(parts, "=> "+resp.Result)
+	}
+	if resp.Limit != "" {
+		parts = append(parts, fmt.Sprintf("⛔ Stopped: hit the %s limit", resp.Limit))
+	} else if resp.Exit != 0 {
+		parts = append(parts, fmt.Sprintf("(exit status %d)", resp.Exit))
+	}
+	if len(parts) == 0 {
+		return "(no output)"
+	}
+	return strings.Join(parts, "\n")
+}
+
+// close shuts the idle connections down when the bot exits.
+func (s *stenchHandler) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.idle {
+		c.conn.Close()
 	}
-	return string(buffer[:n:n])
+	s.idle = nil
 }
@@ -0,0 +1,33 @@
+// Package stench is the wire protocol between the bot and the stench
+// interpreter. Each message is one JSON object on its own line, so programs
+// and output can contain newlines and any length of output arrives whole.
+//
+// The client sends a Request and the server answers with a Response carrying
+// the same ID. Replies to requests the client has given up on can still
+// arrive, so clients skip replies whose ID they aren't waiting for.
+package stench
+
+// Request asks the interpreter to run a program. Programs sent with the same
+// Session share interpreter state, so one can use what an earlier one defined;
+// Reset throws the session's state away first. A Request may reset a session
+// without any Code to run; the bot sends those to check the interpreter is
+// answering.
+type Request struct {
+	ID      uint64 `json:"id"`
+	Session string `json:"session,omitempty"`
+	Reset   bool   `json:"reset,omitempty"`
+	Code    string `json:"code"`
+}
+
+// Response is the outcome of one Request. Result is the value of the last
+// expression, if the interpreter has one; Exit is 0 when the program ran
+// successfully. Limit names the resource limit that stopped the program, such
+// as "CPU time (5s)", if one did.
+type Response struct {
+	ID     uint64 `json:"id"`
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr"`
+	Result string `json:"result"`
+	Exit   int    `json:"exit"`
+	Limit  string `json:"limit,omitempty"`
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"geofbot/stench"
+)
+
+// startStenchStub builds cmd/stenchstub and runs it on a free loopback port,
+// returning a handler for it that treats it as a healthy interpreter.
+func startStenchStub(t *testing.T, wall time.Duration) *stenchHandler {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "stenchstub")
+	if out, err := exec.Command("go", "build", "-o", bin, "./cmd/stenchstub").CombinedOutput(); err != nil {
+		t.Fatalf("building stenchstub: %v\n%s", err, out)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	cmd := exec.Command(bin, "-addr", addr, "-timeout", "10s")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	for deadline := time.Now().Add(10 * time.Second); pingStench(addr) != nil; {
+		if time.Now().After(deadline) {
+			t.Fatal("stenchstub didn't start answering")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	proc := &stenchProcess{addr: addr, limits: evalLimits{Wall: wall}, healthy: true, generation: 1}
+	h := newStenchHandler(addr, proc, 2)
+	t.Cleanup(h.close)
+	return h
+}
+
+func TestStenchEval(t *testing.T) {
+	h := startStenchStub(t, 5*time.Second)
+	tests := []struct {
+		name, code string
+		check      func(stench.Response) bool
+	}{
+		{"multi-line", "echo one\necho two\nexit 3", func(r stench.Response) bool {
+			return r.Stdout == "one\ntwo\n" && r.Exit == 3
+		}},
+		// Replies used to be read into a fixed 1028-byte buffer.
+		{"large reply", `i=0; while [ $i -lt 500 ]; do echo 0123456789; i=$((i+1)); done`, func(r stench.Response) bool {
+			return len(r.Stdout) == 5500 && r.Stdout == strings.Repeat("0123456789\n", 500)
+		}},
+		{"stderr", "echo oops >&2", func(r stench.Response) bool {
+			return r.Stderr == "oops\n" && r.Exit == 0
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := h.eval("test", tt.code, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(resp) {
+				t.Errorf("unexpected response %+v", resp)
+			}
+		})
+	}
+}
+
+func TestStenchAwaitSkipsStaleReplies(t *testing.T) {
+	h := startStenchStub(t, 5*time.Second)
+	conn, err := net.Dial("tcp", h.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := newStenchConn(conn, 1)
+	// The reply to request 1 arrives first, as if it had been given up on.
+	enc := json.NewEncoder(conn)
+	enc.Encode(stench.Request{ID: 1, Code: "echo stale"})
+	enc.Encode(stench.Request{ID: 2, Code: "echo fresh"})
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	resp, err := c.await(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != 2 || resp.Stdout != "fresh\n" {
+		t.Errorf("got %+v, want the reply to request 2", resp)
+	}
+}
+
+func TestStenchRetriesAfterEOF(t *testing.T) {
+	h := startStenchStub(t, 5*time.Second)
+	// An idle connection the interpreter closed: it reads the request and hangs up.
+	client, server := net.Pipe()
+	go func() {
+		bufio.NewReader(server).ReadString('\n')
+		server.Close()
+	}()
+	h.idle = append(h.idle, newStenchConn(client, 1))
+
+	resp, err := h.eval("test", "echo retried", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Stdout != "retried\n" {
+		t.Errorf("got %+v, want the program's output from a fresh connection", resp)
+	}
+}
+
+func TestStenchTimeout(t *testing.T) {
+	h := startStenchStub(t, time.Second)
+	start := time.Now()
+	resp, err := h.eval("test", "sleep 3", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Limit != "wall clock (1s)" {
+		t.Errorf("got limit %q, want the wall clock limit", resp.Limit)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("took %s to give up, want about 1s", elapsed)
+	}
+	if formatted := formatEval(resp); !strings.Contains(formatted, "wall clock") {
+		t.Errorf("formatEval(%+v) = %q, want it to name the limit", resp, formatted)
+	}
+}
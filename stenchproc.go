@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"geofbot/stench"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// stenchStartWait is how long a freshly started interpreter has to start
+	// answering before it counts as unresponsive.
+	stenchStartWait = 30 * time.Second
+	// stenchCheckEvery is how often a running interpreter is health-checked,
+	// and stenchCheckWait how long it has to answer.
+	stenchCheckEvery = 15 * time.Second
+	stenchCheckWait  = 3 * time.Second
+	// stenchMaxFailures health checks failed in a row get the interpreter restarted.
+	stenchMaxFailures = 3
+	// stenchRestartMin is the first restart's backoff. An interpreter that ran
+	// for stenchStableRun before exiting starts again from it.
+	stenchRestartMin = time.Second
+	stenchStableRun  = 5 * time.Minute
+	// stenchLogLines is how much of the interpreter's output is kept for /stench status.
+	stenchLogLines = 100
+	// stenchExitWait is how long a dropped connection waits to learn whether
+	// the interpreter hit a limit.
+	stenchExitWait = 500 * time.Millisecond
+)
+
+// stenchProcess runs the stench interpreter under its limits, restarting it
+// with backoff when it exits, hits a limit or stops answering health checks.
+// Evals are only sent to it while it's healthy.
+type stenchProcess struct {
+	args   []string
+	addr   string
+	limits evalLimits
+	quit   chan struct{}
+	done   chan struct{}
+
+	mu       sync.Mutex
+	state    string
+	healthy  bool
+	pid      int
+	started  time.Time
+	restarts int
+	// generation counts the processes started, so connections to an earlier
+	// one can be told apart.
+	generation int
+	lastExit   string
+	lastExitAt time.Time
+	// exited is the last process to have exited, and exitLimit the limit
+	// that stopped it, if one did.
+	exited    int
+	exitLimit string
+	lastCheck time.Time
+	// kill, if set, asks the running process's monitor to restart it, for
+	// the reason sent.
+	kill chan string
+	logs []string
+	// partial is output not yet ended by a newline.
+	partial string
+}
+
+// newStenchProcess starts the interpreter and a goroutine that keeps it running.
+func newStenchProcess(args []string, addr string, limits evalLimits) *stenchProcess {
+	if limits.Cgroup == "" && limits.Memory > 0 {
+		fmt.Println("stench: no limit.cgroup, so the interpreter's memory isn't limited")
+		limits.Memory = 0
+	}
+	p := &stenchProcess{args: args, addr: addr, limits: limits, quit: make(chan struct{}), done: make(chan struct{}), state: "starting"}
+	go p.run()
+	return p
+}
+
+func (p *stenchProcess) run() {
+	defer close(p.done)
+	backoff := stenchRestartMin
+	for {
+		reason, limit, ran := p.runOnce()
+
+		p.mu.Lock()
+		p.healthy, p.pid, p.kill = false, 0, nil
+		p.lastExit, p.lastExitAt = reason, time.Now()
+		p.exited, p.exitLimit = p.generation, limit
+		if ran > stenchStableRun {
+			backoff = stenchRestartMin
+		}
+		select {
+		case <-p.quit:
+			p.state = "stopped"
+			p.mu.Unlock()
+			return
+		default:
+		}
+		p.state = "restarting"
+		p.restarts++
+		p.mu.Unlock()
+		// The sessions died with the interpreter.
+		evalSessionsMu.Lock()
+		clear(evalSessions)
+		evalSessionsMu.Unlock()
+		fmt.Printf("stench: %s; restarting in %s\n", reason, backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-p.quit:
+			p.mu.Lock()
+			p.state = "stopped"
+			p.mu.Unlock()
+			return
+		}
+		backoff = min(2*backoff, stenchMaxBackoff)
+	}
+}
+
+// runOnce starts the interpreter in its sandbox and watches it until it ends.
+// It returns why it ended, the limit that stopped it if one did, and how long it ran.
+func (p *stenchProcess) runOnce() (reason, limit string, ran time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.args[0], p.args[1:]...)
+	cmd.Stdout, cmd.Stderr = p, p
+	cmd.WaitDelay = time.Second
+	finish, err := sandboxCommand(cmd, p.limits)
+	if err != nil {
+		return "couldn't set up its sandbox: " + err.Error(), "", 0
+	}
+	if err := cmd.Start(); err != nil {
+		finish()
+		return "couldn't start: " + err.Error(), "", 0
+	}
+	kill := make(chan string, 1)
+	started := time.Now()
+	p.mu.Lock()
+	p.state, p.healthy, p.pid, p.started, p.kill = "starting", false, cmd.Process.Pid, started, kill
+	p.generation++
+	p.mu.Unlock()
+	fmt.Println("stench: started pid", cmd.Process.Pid)
+
+	reason, err = p.monitor(cmd, cancel, kill)
+	oom := finish()
+	switch {
+	case reason != "":
+	case oom:
+		limit = fmt.Sprintf("memory (%s)", formatSize(p.limits.Memory))
+	case p.limits.CPU > 0 && cpuLimitHit(cmd.ProcessState, p.limits.CPU):
+		limit = fmt.Sprintf("CPU time (%s)", p.limits.CPU)
+	case err != nil:
+		reason = "exited: " + err.Error()
+	default:
+		reason = "exited"
+	}
+	if limit != "" {
+		reason = "hit the " + limit + " limit"
+	}
+	return reason, limit, time.Since(started)
+}
+
+// monitor health-checks a running interpreter until it exits, killing it if
+// it stops answering or restart or stop asks it to. It returns why it killed
+// the process, or "" if the process exited by itself, and how it exited.
+func (p *stenchProcess) monitor(cmd *exec.Cmd, kill func(), restart chan string) (string, error) {
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	started := time.Now()
+	reason := ""
+	answered := false
+	failures := 0
+	check := time.NewTimer(stenchMinBackoff)
+	defer check.Stop()
+	// quit stays closed once stopping, so it's only waited on until the first kill.
+	quit := p.quit
+	for {
+		select {
+		case err := <-exited:
+			return reason, err
+
+		case <-quit:
+			quit = nil
+			reason = "stopped"
+			kill()
+
+		case why := <-restart:
+			if reason == "" {
+				reason = why
+			}
+			kill()
+
+		case <-check.C:
+			err := pingStench(p.addr)
+			p.mu.Lock()
+			p.lastCheck = time.Now()
+			switch {
+			case err == nil:
+				p.state, p.healthy = "running", true
+				answered, failures = true, 0
+			case !answered && time.Since(started) < stenchStartWait:
+				// Still starting up.
+			default:
+				p.state, p.healthy = "unresponsive", false
+				failures++
+				fmt.Printf("stench: health check %d/%d failed: %v\n", failures, stenchMaxFailures, err)
+			}
+			p.mu.Unlock()
+			if failures >= stenchMaxFailures && reason == "" {
+				reason = fmt.Sprintf("didn't answer %d health checks", failures)
+				kill()
+			}
+			if answered || failures > 0 {
+				check.Reset(stenchCheckEvery)
+			} else {
+				check.Reset(stenchMinBackoff)
+			}
+		}
+	}
+}
+
+// pingStench checks that the interpreter at addr answers requests, by
+// resetting a session no user has.
+func pingStench(addr string) error {
+	conn, err := net.DialTimeout("tcp", addr, stenchDialWait)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(stenchCheckWait))
+	if err := json.NewEncoder(conn).Encode(stench.Request{Session: "health", Reset: true}); err != nil {
+		return err
+	}
+	var resp stench.Response
+	return json.NewDecoder(bufio.NewReader(conn)).Decode(&resp)
+}
+
+// Write captures the interpreter's output, echoing it to the bot's log and
+// keeping the last stenchLogLines lines.
+func (p *stenchProcess) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	lines := strings.Split(p.partial+string(b), "\n")
+	p.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		fmt.Println("stench:", line)
+		p.logs = append(p.logs, line)
+	}
+	if len(p.logs) > stenchLogLines {
+		p.logs = p.logs[len(p.logs)-stenchLogLines:]
+	}
+	return len(b), nil
+}
+
+// ready reports whether evals can be sent to the interpreter, and which
+// process they'd go to.
+func (p *stenchProcess) ready() (generation int, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.generation, p.healthy
+}
+
+// restart kills the interpreter so it's started again, if it's still process
+// generation, or whatever process is running when generation is 0. It
+// reports false if there's no such process.
+func (p *stenchProcess) restart(generation int, reason string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.kill == nil || generation != 0 && generation != p.generation {
+		return false
+	}
+	select {
+	case p.kill <- reason:
+	default:
+	}
+	return true
+}
+
+// limitHit waits briefly for process generation to exit after it dropped a
+// connection, and returns the limit that stopped it, if one did.
+func (p *stenchProcess) limitHit(generation int) string {
+	deadline := time.Now().Add(stenchExitWait)
+	for {
+		p.mu.Lock()
+		exited, limit := p.exited, p.exitLimit
+		p.mu.Unlock()
+		if exited >= generation {
+			if exited == generation {
+				return limit
+			}
+			return ""
+		}
+		if time.Now().After(deadline) {
+			return ""
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// stop kills the interpreter for good and waits for it to exit.
+func (p *stenchProcess) stop() {
+	close(p.quit)
+	<-p.done
+}
+
+// status describes the interpreter for /stench status, ending with the last
+// few lines it logged.
+func (p *stenchProcess) status(logLines int) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**stench** `%s` · %s", strings.Join(p.args, " "), p.state)
+	if p.pid != 0 {
+		fmt.Fprintf(&sb, " · pid %d · up since <t:%d:R>", p.pid, p.started.Unix())
+	}
+	fmt.Fprintf(&sb, "\nLimits: CPU %s · memory %s · %s per eval", formatLimit(p.limits.CPU.String(), p.limits.CPU > 0),
+		formatLimit(formatSize(p.limits.Memory), p.limits.Memory > 0), formatLimit(p.limits.Wall.String(), p.limits.Wall > 0))
+	fmt.Fprintf(&sb, "\nRestarts: %d", p.restarts)
+	if p.lastExit != "" {
+		fmt.Fprintf(&sb, " · last: %s <t:%d:R>", p.lastExit, p.lastExitAt.Unix())
+	}
+	if !p.lastCheck.IsZero() {
+		fmt.Fprintf(&sb, "\nLast health check <t:%d:R>", p.lastCheck.Unix())
+	}
+	var logs []string
+	for _, line := range p.logs[max(len(p.logs)-logLines, 0):] {
+		if r := []rune(line); len(r) > 120 {
+			line = string(r[:120]) + "…"
+		}
+		logs = append(logs, line)
+	}
+	if len(logs) > 0 {
+		sb.WriteString("\n" + codeBlock("", strings.Join(logs, "\n")))
+	}
+	return sb.String()
+}
+
+func handleStench(s *discordgo.Session, i *discordgo.InteractionCreate, proc *stenchProcess, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	if len(options) == 0 {
+		return
+	}
+	// The interpreter is shared by every server, so only the bot's own admins
+	// may look at or restart it.
+	if !isBotAdmin(i) {
+		respondEphemeral(s, i, "Only the bot's admins can manage the interpreter.")
+		return
+	}
+	switch options[0].Name {
+	case "status":
+		respondEphemeral(s, i, proc.status(10))
+	case "restart":
+		if !proc.restart(0, "restarted by an admin") {
+			respondEphemeral(s, i, "The interpreter isn't running; it's already being restarted.")
+			return
+		}
+		respondEphemeral(s, i, "🔄 Restarting the interpreter.")
+	}
+}
+
+func formatLimit(limit string, set bool) string {
+	if !set {
+		return "unlimited"
+	}
+	return limit
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+var DataDir = flag.String("data", "data", "Directory for persisted state")
+
+// loadJSON reads name from the data directory into v. A missing file is not an error.
+func loadJSON(name string, v any) error {
+	content, err := os.ReadFile(filepath.Join(*DataDir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
+
+// saveJSON writes v to name in the data directory, replacing the old file only once the new one is complete.
+func saveJSON(name string, v any) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*DataDir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(*DataDir, name)
+	if err := os.WriteFile(path+".tmp", content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
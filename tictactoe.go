@@ -0,0 +1,496 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type mark uint8
+
+const (
+	noMark mark = iota
+	markX
+	markO
+)
+
+func (m mark) other() mark {
+	return 3 - m
+}
+
+func (m mark) emoji() string {
+	switch m {
+	case markX:
+		return "❌"
+	case markO:
+		return "⭕"
+	}
+	return "⬜"
+}
+
+var tttLines = [8][3]int{
+	{0, 1, 2}, {3, 4, 5}, {6, 7, 8},
+	{0, 3, 6}, {1, 4, 7}, {2, 5, 8},
+	{0, 4, 8}, {2, 4, 6},
+}
+
+// lineWinner returns the mark holding three in a row on a 3x3 grid, if any.
+func lineWinner(cells [9]mark) mark {
+	for _, l := range tttLines {
+		if cells[l[0]] != noMark && cells[l[0]] == cells[l[1]] && cells[l[1]] == cells[l[2]] {
+			return cells[l[0]]
+		}
+	}
+	return noMark
+}
+
+func gridFull(cells [9]mark) bool {
+	for _, c := range cells {
+		if c == noMark {
+			return false
+		}
+	}
+	return true
+}
+
+var tictactoeKind = &gameKind{
+	name:       "ttt",
+	title:      "Tic-tac-toe",
+	minPlayers: 2,
+	maxPlayers: 2,
+	againLabel: "Rematch",
+	newGame: func(players []string) Game {
+		return &tictactoe{XID: players[0], OID: players[1], Turn: markX}
+	},
+	decode: decodeGame[tictactoe],
+}
+
+type tictactoe struct {
+	XID, OID string
+	Board    [9]mark
+	Turn     mark
+	Winner   mark
+	Over     bool
+}
+
+func (g *tictactoe) players() []string { return []string{g.XID, g.OID} }
+
+func (g *tictactoe) turn() string {
+	if g.Turn == markX {
+		return g.XID
+	}
+	return g.OID
+}
+
+func (g *tictactoe) actions() []action {
+	actions := make([]action, 9)
+	for c, m := range g.Board {
+		actions[c] = action{
+			ID:       strconv.Itoa(c),
+			Label:    m.emoji(),
+			Style:    discordgo.SecondaryButton,
+			Disabled: m != noMark,
+			NewRow:   c%3 == 0,
+		}
+	}
+	return actions
+}
+
+func (g *tictactoe) apply(playerID, actionID string) error {
+	c, err := strconv.Atoi(actionID)
+	if err != nil || c < 0 || c >= 9 || g.Board[c] != noMark {
+		return errors.New("That square is taken!")
+	}
+	g.Board[c] = g.Turn
+	g.Winner = lineWinner(g.Board)
+	g.Over = g.Winner != noMark || gridFull(g.Board)
+	g.Turn = g.Turn.other()
+	return nil
+}
+
+func (g *tictactoe) render() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "❌ <@%s> vs ⭕ <@%s>\n", g.XID, g.OID)
+	if !g.Over {
+		fmt.Fprintf(&sb, "%s <@%s>'s turn!", g.Turn.emoji(), g.turn())
+		return sb.String()
+	}
+	sb.WriteString("\n")
+	for c, m := range g.Board {
+		sb.WriteString(m.emoji())
+		if c%3 == 2 {
+			sb.WriteString("\n")
+		}
+	}
+	sb.WriteString("\n")
+	sb.WriteString(tttResult(g.Winner, g.XID, g.OID))
+	return sb.String()
+}
+
+func tttResult(winner mark, xID, oID string) string {
+	switch winner {
+	case markX:
+		return fmt.Sprintf("❌ <@%s> wins!", xID)
+	case markO:
+		return fmt.Sprintf("⭕ <@%s> wins!", oID)
+	}
+	return "It's a draw!"
+}
+
+func (g *tictactoe) outcome() (bool, string) {
+	switch {
+	case !g.Over:
+		return false, ""
+	case g.Winner == markX:
+		return true, g.XID
+	case g.Winner == markO:
+		return true, g.OID
+	}
+	return true, ""
+}
+
+// botMove searches the whole game tree, so the bot never loses.
+func (g *tictactoe) botMove() string {
+	best, bestScore := -1, 0
+	board := g.Board
+	for c := range board {
+		if board[c] != noMark {
+			continue
+		}
+		board[c] = g.Turn
+		score := -tttNegamax(board, g.Turn.other(), 1)
+		board[c] = noMark
+		if best < 0 || score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return strconv.Itoa(best)
+}
+
+// tttNegamax scores a position for the player to move, preferring quicker wins and slower losses.
+func tttNegamax(board [9]mark, toMove mark, depth int) int {
+	if lineWinner(board) != noMark {
+		// Only the previous mover can have just completed a line.
+		return depth - 10
+	}
+	best, moved := 0, false
+	for c := range board {
+		if board[c] != noMark {
+			continue
+		}
+		board[c] = toMove
+		score := -tttNegamax(board, toMove.other(), depth+1)
+		board[c] = noMark
+		if !moved || score > best {
+			best, moved = score, true
+		}
+	}
+	return best
+}
+
+var ultimateKind = &gameKind{
+	name:       "uttt",
+	title:      "Ultimate tic-tac-toe",
+	minPlayers: 2,
+	maxPlayers: 2,
+	againLabel: "Rematch",
+	newGame: func(players []string) Game {
+		return &ultimateTTT{XID: players[0], OID: players[1], Turn: markX, Active: -1, Selected: -1}
+	},
+	decode: decodeGame[ultimateTTT],
+}
+
+// ultimateTTT is tic-tac-toe played on nine sub-boards. The square a player
+// picks sends their opponent to the matching sub-board; if that board is
+// already closed the opponent may play anywhere.
+type ultimateTTT struct {
+	XID, OID string
+	Boards   [9][9]mark
+	Won      [9]mark
+	// Active is the sub-board the next move must be played in, or -1 for any.
+	Active int
+	// Selected is the sub-board the player picked when free to choose, or -1.
+	Selected int
+	Turn     mark
+	Winner   mark
+	Over     bool
+}
+
+func (g *ultimateTTT) players() []string { return []string{g.XID, g.OID} }
+
+func (g *ultimateTTT) turn() string {
+	if g.Turn == markX {
+		return g.XID
+	}
+	return g.OID
+}
+
+func (g *ultimateTTT) closed(b int) bool {
+	return g.Won[b] != noMark || gridFull(g.Boards[b])
+}
+
+// board returns the sub-board the player to move is playing in, or -1 if they still need to pick one.
+func (g *ultimateTTT) board() int {
+	if g.Active >= 0 {
+		return g.Active
+	}
+	return g.Selected
+}
+
+func (g *ultimateTTT) actions() []action {
+	b := g.board()
+	actions := make([]action, 0, 10)
+	if b < 0 {
+		for sb := range 9 {
+			label := strconv.Itoa(sb + 1)
+			if g.Won[sb] != noMark {
+				label = g.Won[sb].emoji()
+			} else if g.closed(sb) {
+				label = "➖"
+			}
+			actions = append(actions, action{
+				ID:       "b" + strconv.Itoa(sb),
+				Label:    label,
+				Disabled: g.closed(sb),
+				NewRow:   sb%3 == 0,
+			})
+		}
+		return actions
+	}
+	for c, m := range g.Boards[b] {
+		actions = append(actions, action{
+			ID:       "c" + strconv.Itoa(c),
+			Label:    m.emoji(),
+			Style:    discordgo.SecondaryButton,
+			Disabled: m != noMark,
+			NewRow:   c%3 == 0,
+		})
+	}
+	if g.Active < 0 {
+		actions = append(actions, action{ID: "back", Label: "Pick another board", NewRow: true})
+	}
+	return actions
+}
+
+// apply accepts "b<n>" to pick a sub-board, "back" to un-pick it, "c<n>" to
+// play a square in the current sub-board, and "p<b><c>" to play any legal square directly.
+func (g *ultimateTTT) apply(playerID, actionID string) error {
+	switch {
+	case actionID == "back":
+		g.Selected = -1
+		return nil
+	case strings.HasPrefix(actionID, "b"):
+		b, err := strconv.Atoi(actionID[1:])
+		if err != nil || g.Active >= 0 || b < 0 || b >= 9 || g.closed(b) {
+			return errors.New("You can't play on that board.")
+		}
+		g.Selected = b
+		return nil
+	case strings.HasPrefix(actionID, "c"):
+		c, err := strconv.Atoi(actionID[1:])
+		if err != nil || g.board() < 0 {
+			return errors.New("Pick a board first.")
+		}
+		return g.play(g.board(), c)
+	case strings.HasPrefix(actionID, "p") && len(actionID) == 3:
+		b, c := int(actionID[1]-'0'), int(actionID[2]-'0')
+		if g.Active >= 0 && b != g.Active {
+			return errors.New("You can't play on that board.")
+		}
+		return g.play(b, c)
+	}
+	return fmt.Errorf("unknown ultimate tic-tac-toe action %q", actionID)
+}
+
+func (g *ultimateTTT) play(b, c int) error {
+	if b < 0 || b >= 9 || c < 0 || c >= 9 || g.closed(b) || g.Boards[b][c] != noMark {
+		return errors.New("That square is taken!")
+	}
+	g.Boards[b][c] = g.Turn
+	if g.Won[b] = lineWinner(g.Boards[b]); g.Won[b] != noMark {
+		g.Winner = lineWinner(g.Won)
+	}
+	g.Over = g.Winner != noMark
+	if !g.Over {
+		g.Over = true
+		for sb := range 9 {
+			if !g.closed(sb) {
+				g.Over = false
+				break
+			}
+		}
+	}
+	g.Active, g.Selected = c, -1
+	if g.closed(c) {
+		g.Active = -1
+	}
+	g.Turn = g.Turn.other()
+	return nil
+}
+
+func (g *ultimateTTT) render() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "❌ <@%s> vs ⭕ <@%s>\n\n", g.XID, g.OID)
+	for row := range 9 {
+		for col := range 9 {
+			b, c := row/3*3+col/3, row%3*3+col%3
+			m := g.Boards[b][c]
+			// Won boards are filled with the winner's mark so they stand out.
+			if g.Won[b] != noMark {
+				m = g.Won[b]
+			}
+			sb.WriteString(m.emoji())
+			if col == 2 || col == 5 {
+				sb.WriteString("  ")
+			}
+		}
+		sb.WriteString("\n")
+		if row == 2 || row == 5 {
+			sb.WriteString("\n")
+		}
+	}
+	sb.WriteString("\n")
+	switch {
+	case g.Over:
+		sb.WriteString(tttResult(g.Winner, g.XID, g.OID))
+	case g.board() >= 0:
+		fmt.Fprintf(&sb, "%s <@%s>'s turn on board %d!", g.Turn.emoji(), g.turn(), g.board()+1)
+	default:
+		fmt.Fprintf(&sb, "%s <@%s>'s turn: pick any open board!", g.Turn.emoji(), g.turn())
+	}
+	return sb.String()
+}
+
+func (g *ultimateTTT) outcome() (bool, string) {
+	switch {
+	case !g.Over:
+		return false, ""
+	case g.Winner == markX:
+		return true, g.XID
+	case g.Winner == markO:
+		return true, g.OID
+	}
+	return true, ""
+}
+
+// ultimateDepth is how many plies the bot searches. The full game tree is far
+// too large to solve, so past this depth positions are scored heuristically.
+const ultimateDepth = 6
+
+// botMove plays the best move the depth-limited search finds. Unlike the
+// classic bot it can be beaten.
+func (g *ultimateTTT) botMove() string {
+	best, _ := ultimateSearch(*g, ultimateDepth, -1<<30, 1<<30)
+	return best
+}
+
+func (g *ultimateTTT) legalMoves() [][2]int {
+	var moves [][2]int
+	for b := range 9 {
+		if (g.Active >= 0 && b != g.Active) || g.closed(b) {
+			continue
+		}
+		for c := range 9 {
+			if g.Boards[b][c] == noMark {
+				moves = append(moves, [2]int{b, c})
+			}
+		}
+	}
+	return moves
+}
+
+// ultimateSearch is an alpha-beta negamax search returning the best move and
+// its score for the player to move.
+func ultimateSearch(g ultimateTTT, depth, alpha, beta int) (string, int) {
+	if g.Over || depth == 0 {
+		return "", g.evaluate(depth)
+	}
+	moves := g.legalMoves()
+	// Being sent anywhere multiplies the branching factor, so look less deep.
+	if len(moves) > 9 && depth > 3 {
+		depth = 3
+	}
+	best, bestScore := "", -1<<30
+	for _, m := range moves {
+		next := g
+		next.play(m[0], m[1])
+		_, score := ultimateSearch(next, depth-1, -beta, -alpha)
+		score = -score
+		if score > bestScore {
+			best, bestScore = fmt.Sprintf("p%d%d", m[0], m[1]), score
+		}
+		alpha = max(alpha, score)
+		if alpha >= beta {
+			break
+		}
+	}
+	return best, bestScore
+}
+
+// evaluate scores the position for the player to move. Remaining depth is
+// added to wins so the bot prefers winning sooner.
+func (g *ultimateTTT) evaluate(depth int) int {
+	if g.Over {
+		switch g.Winner {
+		case noMark:
+			return 0
+		case g.Turn:
+			return 100000 + depth
+		default:
+			return -100000 - depth
+		}
+	}
+	boardWeight := [9]int{3, 2, 3, 2, 4, 2, 3, 2, 3}
+	score := 0
+	for b := range 9 {
+		switch g.Won[b] {
+		case g.Turn:
+			score += 100 * boardWeight[b]
+		case g.Turn.other():
+			score -= 100 * boardWeight[b]
+		case noMark:
+			score += 5 * (lineThreats(g.Boards[b], g.Turn) - lineThreats(g.Boards[b], g.Turn.other()))
+		}
+	}
+	score += 300 * (lineThreats(g.Won, g.Turn) - lineThreats(g.Won, g.Turn.other()))
+	// Sending the opponent to a free choice hands them the initiative.
+	if g.Active < 0 {
+		score += 40
+	}
+	return score
+}
+
+// lineThreats counts lines where m holds two squares and the third is open.
+func lineThreats(cells [9]mark, m mark) int {
+	n := 0
+	for _, l := range tttLines {
+		mine, open := 0, 0
+		for _, c := range l {
+			switch cells[c] {
+			case m:
+				mine++
+			case noMark:
+				open++
+			}
+		}
+		if mine == 2 && open == 1 {
+			n++
+		}
+	}
+	return n
+}
+
+func handleTictactoe(s *discordgo.Session, i *discordgo.InteractionCreate, om optionMap) {
+	kind := tictactoeKind.name
+	if opt, ok := om["variant"]; ok {
+		kind = opt.StringValue()
+	}
+	if opt, ok := om["bot"]; ok && opt.BoolValue() {
+		host.open(s, i, kind, s.State.User.ID)
+		return
+	}
+	host.open(s, i, kind)
+}
@@ -0,0 +1,542 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type tournamentFormat string
+
+const (
+	singleElimination tournamentFormat = "single"
+	roundRobin        tournamentFormat = "roundrobin"
+)
+
+const (
+	maxTournamentPlayers = 32
+	tournamentsFile      = "tournaments.json"
+)
+
+// match is a single pairing in a tournament round. B is empty for a bye.
+type match struct {
+	A, B    string
+	Winner  string
+	Draw    bool
+	Done    bool
+	Replays int
+	// GameID is the Connect 4 game being played for the match.
+	GameID string
+}
+
+type tournament struct {
+	ChannelID string
+	OwnerID   string
+	Format    tournamentFormat
+	Players   []string
+	Started   bool
+	Finished  bool
+	Round     int
+	Rounds    [][]*match
+	// Points holds round robin standings in half points (win = 2, draw = 1).
+	Points map[string]int
+	// Schedule holds the precomputed round robin pairings.
+	Schedule [][]*match
+}
+
+// tournaments are keyed by channel ID, so each channel runs at most one tournament at a time.
+var (
+	tournaments   = make(map[string]*tournament)
+	tournamentsMu sync.Mutex
+)
+
+// tournamentPosts collects the messages and games a change to a tournament
+// calls for, so they can be sent once tournamentsMu is released.
+type tournamentPosts struct {
+	t        *tournament
+	messages []string
+	matches  []pendingMatch
+}
+
+// pendingMatch is a match waiting for its game, with the players in seat order.
+type pendingMatch struct {
+	m       *match
+	players []string
+}
+
+// loadTournaments restores persisted tournaments and reconnects their running
+// matches to their games, so it must run after host.load.
+func loadTournaments() {
+	tournamentsMu.Lock()
+	defer tournamentsMu.Unlock()
+	tournaments = make(map[string]*tournament)
+	if err := loadJSON(tournamentsFile, &tournaments); err != nil {
+		fmt.Println("loadTournaments error:", err)
+	}
+	for _, t := range tournaments {
+		if !t.Started || t.Finished {
+			continue
+		}
+		for _, m := range t.Rounds[t.Round] {
+			if !m.Done && m.B != "" && !host.setOnEnd(m.GameID, t.matchEnded(m)) {
+				fmt.Println("loadTournaments: no game for match", t.ChannelID, m.A, m.B)
+			}
+		}
+	}
+}
+
+// saveTournaments persists every tournament. Callers must hold tournamentsMu.
+func saveTournaments() {
+	if err := saveJSON(tournamentsFile, tournaments); err != nil {
+		fmt.Println("tournament save error:", err)
+	}
+}
+
+func handleTournament(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	userID := interactionUserID(i)
+	if len(options) == 0 {
+		return
+	}
+	sub := options[0]
+	om := parseOptions(sub.Options)
+
+	tournamentsMu.Lock()
+	t := tournaments[i.ChannelID]
+	posts := &tournamentPosts{t: t}
+
+	var reply string
+	// more holds any further messages the reply runs on to.
+	var more []string
+	ephemeral := true
+	switch sub.Name {
+	case "create":
+		if t != nil && !t.Finished {
+			reply = "There is already a tournament in this channel."
+			break
+		}
+		format := singleElimination
+		if opt, ok := om["format"]; ok {
+			format = tournamentFormat(opt.StringValue())
+		}
+		tournaments[i.ChannelID] = &tournament{
+			ChannelID: i.ChannelID,
+			OwnerID:   userID,
+			Format:    format,
+			Players:   []string{userID},
+		}
+		saveTournaments()
+		reply = fmt.Sprintf("<@%s> created a %s Connect 4 tournament! Use `/tournament join` to enter.", userID, format.label())
+		ephemeral = false
+	case "join":
+		switch {
+		case t == nil || t.Finished:
+			reply = "There is no tournament in this channel. Use `/tournament create` to start one."
+		case t.Started:
+			reply = "This tournament has already started."
+		case t.hasPlayer(userID):
+			reply = "You have already joined this tournament."
+		case len(t.Players) >= maxTournamentPlayers:
+			reply = "This tournament is full."
+		default:
+			t.Players = append(t.Players, userID)
+			saveTournaments()
+			reply = fmt.Sprintf("<@%s> joined the tournament (%d players).", userID, len(t.Players))
+			ephemeral = false
+		}
+	case "start":
+		switch {
+		case t == nil || t.Finished:
+			reply = "There is no tournament in this channel."
+		case t.OwnerID != userID:
+			reply = "Only the tournament creator can start it."
+		case t.Started:
+			reply = "This tournament has already started."
+		case len(t.Players) < 2:
+			reply = "At least 2 players are needed to start."
+		default:
+			t.start()
+			bracket := t.renderBracket()
+			reply, more = bracket[0], bracket[1:]
+			ephemeral = false
+			t.startRound(posts)
+			saveTournaments()
+		}
+	case "forfeit":
+		playerID := userID
+		if opt, ok := om["player"]; ok {
+			playerID = opt.UserValue(nil).ID
+		}
+		switch {
+		case t == nil || !t.Started || t.Finished:
+			reply = "There is no tournament being played in this channel."
+		case playerID != userID && !t.managedBy(i):
+			reply = "Only the tournament creator or a server manager can forfeit someone else's match."
+		default:
+			m := t.currentMatch(playerID)
+			if m == nil {
+				reply = fmt.Sprintf("<@%s> has no match to forfeit in this round.", playerID)
+				break
+			}
+			opponent := m.A
+			if opponent == playerID {
+				opponent = m.B
+			}
+			reply = fmt.Sprintf("<@%s> forfeits their match against <@%s>.", playerID, opponent)
+			ephemeral = false
+			t.finishMatch(posts, m, opponent)
+			saveTournaments()
+		}
+	case "cancel":
+		switch {
+		case t == nil || t.Finished:
+			reply = "There is no tournament in this channel."
+		case !t.managedBy(i):
+			reply = "Only the tournament creator or a server manager can cancel it."
+		default:
+			// Finishing it stops its games from reporting back once they end.
+			t.Finished = true
+			delete(tournaments, i.ChannelID)
+			saveTournaments()
+			reply = fmt.Sprintf("<@%s> cancelled the tournament.", userID)
+			ephemeral = false
+		}
+	case "bracket":
+		if t == nil {
+			reply = "There is no tournament in this channel."
+			break
+		}
+		bracket := t.renderBracket()
+		reply, more = bracket[0], bracket[1:]
+	}
+	tournamentsMu.Unlock()
+
+	data := &discordgo.InteractionResponseData{Content: reply}
+	if ephemeral {
+		data.Flags = discordgo.MessageFlagsEphemeral
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+	if err != nil {
+		fmt.Println("handleTournament respond error:", err)
+	}
+	for _, content := range more {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{Content: content, Flags: data.Flags})
+		if err != nil {
+			fmt.Println("handleTournament followup error:", err)
+		}
+	}
+	posts.send(s)
+}
+
+// send posts the collected messages to the tournament's channel and starts
+// the collected games.
+func (p *tournamentPosts) send(s *discordgo.Session) {
+	for _, content := range p.messages {
+		if _, err := s.ChannelMessageSend(p.t.ChannelID, content); err != nil {
+			fmt.Println("tournament post error:", err)
+		}
+	}
+	for _, pm := range p.matches {
+		gameID := host.spawn(s, connect4Kind.name, p.t.ChannelID, pm.players, p.t.matchEnded(pm.m))
+		tournamentsMu.Lock()
+		pm.m.GameID = gameID
+		saveTournaments()
+		tournamentsMu.Unlock()
+	}
+}
+
+// spawn queues a game for m. Colours swap on replays so a drawn match isn't
+// decided by who moves first.
+func (p *tournamentPosts) spawn(m *match) {
+	players := []string{m.A, m.B}
+	if m.Replays%2 == 1 {
+		players = []string{m.B, m.A}
+	}
+	p.matches = append(p.matches, pendingMatch{m: m, players: players})
+}
+
+// managedBy reports whether the interaction's user can cancel the tournament
+// or forfeit others' matches: its creator or a server manager.
+func (t *tournament) managedBy(i *discordgo.InteractionCreate) bool {
+	return interactionUserID(i) == t.OwnerID || i.Member != nil && i.Member.Permissions&discordgo.PermissionManageServer != 0
+}
+
+// currentMatch returns the player's unfinished match in the current round, or nil.
+func (t *tournament) currentMatch(playerID string) *match {
+	for _, m := range t.Rounds[t.Round] {
+		if !m.Done && m.B != "" && (m.A == playerID || m.B == playerID) {
+			return m
+		}
+	}
+	return nil
+}
+
+func (f tournamentFormat) label() string {
+	if f == roundRobin {
+		return "round-robin"
+	}
+	return "single-elimination"
+}
+
+func (t *tournament) hasPlayer(userID string) bool {
+	for _, p := range t.Players {
+		if p == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// start seeds the players and builds the first round.
+func (t *tournament) start() {
+	t.Started = true
+	rand.Shuffle(len(t.Players), func(a, b int) {
+		t.Players[a], t.Players[b] = t.Players[b], t.Players[a]
+	})
+	if t.Format == roundRobin {
+		t.Points = make(map[string]int)
+		t.Schedule = roundRobinSchedule(t.Players)
+		t.Rounds = [][]*match{t.Schedule[0]}
+		return
+	}
+	size := 1
+	for size < len(t.Players) {
+		size *= 2
+	}
+	seeds := make([]string, size)
+	copy(seeds, t.Players)
+	// Byes sit at the end of the seed list, so pairing seed k with seed size-1-k
+	// never puts two byes against each other.
+	round := make([]*match, size/2)
+	for k := range round {
+		round[k] = &match{A: seeds[k], B: seeds[size-1-k]}
+	}
+	t.Rounds = [][]*match{round}
+}
+
+// roundRobinSchedule pairs every player with every other player once using the circle method.
+func roundRobinSchedule(players []string) [][]*match {
+	ring := append([]string{}, players...)
+	if len(ring)%2 == 1 {
+		ring = append(ring, "")
+	}
+	n := len(ring)
+	rounds := make([][]*match, n-1)
+	for r := range rounds {
+		for k := 0; k < n/2; k++ {
+			a, b := ring[k], ring[n-1-k]
+			if a == "" {
+				a, b = b, a
+			}
+			rounds[r] = append(rounds[r], &match{A: a, B: b})
+		}
+		// Keep the first player fixed and rotate everyone else one seat.
+		last := ring[n-1]
+		copy(ring[2:], ring[1:n-1])
+		ring[1] = last
+	}
+	return rounds
+}
+
+// startRound settles byes in the current round and queues a Connect 4 game
+// for every other match.
+func (t *tournament) startRound(p *tournamentPosts) {
+	for _, m := range t.Rounds[t.Round] {
+		if m.B == "" {
+			m.Winner = m.A
+			m.Done = true
+			continue
+		}
+		p.spawn(m)
+	}
+	if t.roundDone() {
+		t.advance(p)
+	}
+}
+
+// matchEnded returns the callback that reports the result of m's game.
+func (t *tournament) matchEnded(m *match) func(s *discordgo.Session, g Game) {
+	return func(s *discordgo.Session, g Game) {
+		tournamentsMu.Lock()
+		p := &tournamentPosts{t: t}
+		t.report(p, m, g)
+		saveTournaments()
+		tournamentsMu.Unlock()
+		p.send(s)
+	}
+}
+
+// report records the result of a finished match game and advances the tournament when the round is over.
+func (t *tournament) report(p *tournamentPosts, m *match, g Game) {
+	if t.Finished || m.Done {
+		return
+	}
+	_, winner := g.outcome()
+	if winner == "" && t.Format == singleElimination {
+		m.Replays++
+		p.messages = append(p.messages, fmt.Sprintf("<@%s> and <@%s> drew, so they play again with colours swapped.", m.A, m.B))
+		p.spawn(m)
+		return
+	}
+	t.finishMatch(p, m, winner)
+}
+
+// finishMatch records a match's winner, "" for a draw, and advances the
+// tournament when the round is over.
+func (t *tournament) finishMatch(p *tournamentPosts, m *match, winner string) {
+	m.Done = true
+	m.Winner = winner
+	m.Draw = winner == ""
+	if t.Format == roundRobin {
+		if m.Draw {
+			t.Points[m.A]++
+			t.Points[m.B]++
+		} else {
+			t.Points[winner] += 2
+		}
+	}
+	if t.roundDone() {
+		t.advance(p)
+	}
+}
+
+func (t *tournament) roundDone() bool {
+	for _, m := range t.Rounds[t.Round] {
+		if !m.Done {
+			return false
+		}
+	}
+	return true
+}
+
+// advance posts the updated bracket and either starts the next round or finishes the tournament.
+func (t *tournament) advance(p *tournamentPosts) {
+	switch t.Format {
+	case roundRobin:
+		if t.Round+1 >= len(t.Schedule) {
+			t.Finished = true
+		} else {
+			t.Round++
+			t.Rounds = append(t.Rounds, t.Schedule[t.Round])
+		}
+	default:
+		prev := t.Rounds[t.Round]
+		if len(prev) == 1 {
+			t.Finished = true
+			break
+		}
+		next := make([]*match, len(prev)/2)
+		for k := range next {
+			next[k] = &match{A: prev[2*k].Winner, B: prev[2*k+1].Winner}
+		}
+		t.Round++
+		t.Rounds = append(t.Rounds, next)
+	}
+	p.messages = append(p.messages, t.renderBracket()...)
+	if !t.Finished {
+		t.startRound(p)
+	}
+}
+
+// renderBracket shows the current round, and the standings or champion, as
+// messages that each fit Discord's 2000 character limit. Earlier rounds are
+// left out, as a full bracket of 32 players wouldn't fit in one message.
+func (t *tournament) renderBracket() []string {
+	lines := []string{fmt.Sprintf("🏆 **Connect 4 %s tournament** (%d players)", t.Format.label(), len(t.Players))}
+	if !t.Started {
+		mentions := make([]string, len(t.Players))
+		for k, p := range t.Players {
+			mentions[k] = fmt.Sprintf("<@%s>", p)
+		}
+		lines = append(lines, "Players: "+strings.Join(mentions, ", "), "Waiting for the creator to `/tournament start`.")
+		return packLines(lines)
+	}
+	rounds := len(t.Schedule)
+	if t.Format == singleElimination {
+		rounds = 1
+		for n := len(t.Rounds[0]); n > 1; n /= 2 {
+			rounds++
+		}
+	}
+	lines = append(lines, "", fmt.Sprintf("**Round %d of %d**", t.Round+1, rounds))
+	for _, m := range t.Rounds[t.Round] {
+		switch {
+		case m.B == "":
+			lines = append(lines, fmt.Sprintf("<@%s> has a bye", m.A))
+		case !m.Done:
+			lines = append(lines, fmt.Sprintf("<@%s> vs <@%s> — playing", m.A, m.B))
+		case m.Draw:
+			lines = append(lines, fmt.Sprintf("<@%s> vs <@%s> — draw", m.A, m.B))
+		default:
+			lines = append(lines, fmt.Sprintf("<@%s> vs <@%s> — <@%s> wins", m.A, m.B, m.Winner))
+		}
+	}
+	if t.Format == roundRobin {
+		lines = append(lines, "", "**Standings**")
+		for _, p := range t.standings() {
+			lines = append(lines, fmt.Sprintf("<@%s> — %s pts", p, halfPoints(t.Points[p])))
+		}
+	}
+	if t.Finished {
+		lines = append(lines, "", t.championLine())
+	}
+	return packLines(lines)
+}
+
+// packLines joins lines into as few messages of at most 2000 bytes as it can.
+func packLines(lines []string) []string {
+	var messages []string
+	var sb strings.Builder
+	for _, line := range lines {
+		if sb.Len() > 0 && sb.Len()+1+len(line) > 2000 {
+			messages = append(messages, sb.String())
+			sb.Reset()
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(line)
+	}
+	return append(messages, sb.String())
+}
+
+// standings returns the players ordered by points, highest first.
+func (t *tournament) standings() []string {
+	players := append([]string{}, t.Players...)
+	for a := 1; a < len(players); a++ {
+		for b := a; b > 0 && t.Points[players[b]] > t.Points[players[b-1]]; b-- {
+			players[b], players[b-1] = players[b-1], players[b]
+		}
+	}
+	return players
+}
+
+func (t *tournament) championLine() string {
+	if t.Format == singleElimination {
+		return fmt.Sprintf("🎉 <@%s> is the champion!", t.Rounds[len(t.Rounds)-1][0].Winner)
+	}
+	standings := t.standings()
+	best := t.Points[standings[0]]
+	var winners []string
+	for _, p := range standings {
+		if t.Points[p] == best {
+			winners = append(winners, fmt.Sprintf("<@%s>", p))
+		}
+	}
+	if len(winners) == 1 {
+		return fmt.Sprintf("🎉 %s is the champion!", winners[0])
+	}
+	return fmt.Sprintf("🎉 %s share first place!", strings.Join(winners, ", "))
+}
+
+func halfPoints(n int) string {
+	if n%2 == 0 {
+		return fmt.Sprintf("%d", n/2)
+	}
+	return fmt.Sprintf("%d.5", n/2)
+}
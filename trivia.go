@@ -0,0 +1,514 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+//go:embed trivia/*.json
+var triviaFS embed.FS
+
+// triviaMinRounds is a variable since command options take its address.
+var triviaMinRounds = 1.0
+
+const (
+	triviaFile          = "trivia.json"
+	triviaAnswerTime    = 20 * time.Second
+	triviaPause         = 5 * time.Second
+	triviaDefaultRounds = 5
+	triviaMaxRounds     = 20
+	triviaMaxUpload     = 256 << 10
+)
+
+// triviaQuestion lists the right answer first; choices are shuffled when asked.
+type triviaQuestion struct {
+	Question string   `json:"question"`
+	Answers  []string `json:"answers"`
+}
+
+type triviaPack struct {
+	Name      string           `json:"name"`
+	Questions []triviaQuestion `json:"questions"`
+}
+
+func (p *triviaPack) validate() error {
+	if p.Name == "" || strings.ContainsAny(p.Name, " \n") {
+		return errors.New("The pack needs a one-word name.")
+	}
+	if len(p.Questions) == 0 {
+		return errors.New("The pack has no questions.")
+	}
+	for k, q := range p.Questions {
+		if strings.TrimSpace(q.Question) == "" || len(q.Question) > 1000 {
+			return fmt.Errorf("Question %d needs some text (at most 1000 characters).", k+1)
+		}
+		if len(q.Answers) < 2 || len(q.Answers) > 4 {
+			return fmt.Errorf("Question %d needs between 2 and 4 answers.", k+1)
+		}
+		for _, a := range q.Answers {
+			if strings.TrimSpace(a) == "" || len(a) > 80 {
+				return fmt.Errorf("Answers to question %d must be 1 to 80 characters.", k+1)
+			}
+		}
+	}
+	return nil
+}
+
+// triviaSource supplies question packs for a guild.
+type triviaSource interface {
+	packs(guildID string) []*triviaPack
+}
+
+// triviaSources are searched in order, so a guild can't shadow a built-in pack.
+var triviaSources = []triviaSource{embeddedTrivia{}, guildTrivia{}}
+
+// embeddedTrivia serves the packs built into the bot.
+type embeddedTrivia struct{}
+
+var embeddedTriviaPacks = func() []*triviaPack {
+	files, _ := triviaFS.ReadDir("trivia")
+	var packs []*triviaPack
+	for _, f := range files {
+		b, err := triviaFS.ReadFile(path.Join("trivia", f.Name()))
+		if err != nil {
+			panic(err)
+		}
+		p := &triviaPack{}
+		if err := json.Unmarshal(b, p); err != nil {
+			panic(fmt.Sprintf("trivia pack %s: %v", f.Name(), err))
+		}
+		packs = append(packs, p)
+	}
+	return packs
+}()
+
+func (embeddedTrivia) packs(string) []*triviaPack { return embeddedTriviaPacks }
+
+// guildTrivia serves packs uploaded to a guild with /trivia upload.
+type guildTrivia struct{}
+
+var (
+	// triviaGuildPacks maps guild ID to pack name to pack.
+	triviaGuildPacks   map[string]map[string]*triviaPack
+	triviaGuildPacksMu sync.Mutex
+)
+
+func loadTrivia() {
+	triviaGuildPacksMu.Lock()
+	defer triviaGuildPacksMu.Unlock()
+	triviaGuildPacks = make(map[string]map[string]*triviaPack)
+	if err := loadJSON(triviaFile, &triviaGuildPacks); err != nil {
+		fmt.Println("loadTrivia error:", err)
+	}
+}
+
+func (guildTrivia) packs(guildID string) []*triviaPack {
+	triviaGuildPacksMu.Lock()
+	defer triviaGuildPacksMu.Unlock()
+	var packs []*triviaPack
+	for _, p := range triviaGuildPacks[guildID] {
+		packs = append(packs, p)
+	}
+	sort.Slice(packs, func(a, b int) bool { return packs[a].Name < packs[b].Name })
+	return packs
+}
+
+func addGuildTriviaPack(guildID string, p *triviaPack) error {
+	triviaGuildPacksMu.Lock()
+	defer triviaGuildPacksMu.Unlock()
+	if triviaGuildPacks[guildID] == nil {
+		triviaGuildPacks[guildID] = make(map[string]*triviaPack)
+	}
+	triviaGuildPacks[guildID][p.Name] = p
+	return saveJSON(triviaFile, triviaGuildPacks)
+}
+
+func triviaPacks(guildID string) []*triviaPack {
+	var packs []*triviaPack
+	for _, src := range triviaSources {
+		packs = append(packs, src.packs(guildID)...)
+	}
+	return packs
+}
+
+// findTriviaPack returns the named pack, or every question available when name is empty.
+func findTriviaPack(guildID, name string) *triviaPack {
+	all := &triviaPack{Name: "everything"}
+	for _, p := range triviaPacks(guildID) {
+		if strings.EqualFold(p.Name, name) {
+			return p
+		}
+		all.Questions = append(all.Questions, p.Questions...)
+	}
+	if name == "" {
+		return all
+	}
+	return nil
+}
+
+type triviaRound struct {
+	Question string
+	Choices  []string
+	Correct  int
+}
+
+type triviaAnswer struct {
+	Choice int
+	At     time.Time
+}
+
+// trivia is a quiz running in one channel. It isn't persisted, since its
+// timers wouldn't survive a restart anyway.
+type trivia struct {
+	ID        string
+	ChannelID string
+	MessageID string
+	Pack      string
+	Rounds    []triviaRound
+	Round     int
+	Asked     time.Time
+	Open      bool
+	Answers   map[string]triviaAnswer
+	Scores    map[string]int
+	// Gained is what each player scored on the last question.
+	Gained map[string]int
+}
+
+var (
+	// triviaGames maps channel ID to the quiz running there.
+	triviaGames   = make(map[string]*trivia)
+	triviaGamesMu sync.Mutex
+)
+
+func newTrivia(channelID string, pack *triviaPack, rounds int) *trivia {
+	t := &trivia{
+		ID:        newGameID(),
+		ChannelID: channelID,
+		Pack:      pack.Name,
+		Scores:    make(map[string]int),
+	}
+	for _, k := range rand.Perm(len(pack.Questions))[:min(rounds, len(pack.Questions))] {
+		q := pack.Questions[k]
+		r := triviaRound{Question: q.Question}
+		for _, a := range rand.Perm(len(q.Answers)) {
+			if a == 0 {
+				r.Correct = len(r.Choices)
+			}
+			r.Choices = append(r.Choices, q.Answers[a])
+		}
+		t.Rounds = append(t.Rounds, r)
+	}
+	return t
+}
+
+// ask opens the next question.
+func (t *trivia) ask() {
+	t.Open = true
+	t.Asked = time.Now()
+	t.Answers = make(map[string]triviaAnswer)
+}
+
+// close scores the open question. Right answers earn 500 to 1000 points
+// depending on how quickly they came in.
+func (t *trivia) close() {
+	t.Open = false
+	t.Gained = make(map[string]int)
+	r := t.Rounds[t.Round]
+	for userID, a := range t.Answers {
+		if a.Choice != r.Correct {
+			continue
+		}
+		left := max(triviaAnswerTime-a.At.Sub(t.Asked), 0)
+		points := 500 + int(500*left/triviaAnswerTime)
+		t.Gained[userID] = points
+		t.Scores[userID] += points
+	}
+}
+
+func (t *trivia) last() bool {
+	return t.Round == len(t.Rounds)-1
+}
+
+func (t *trivia) message() (string, []discordgo.MessageComponent) {
+	r := t.Rounds[t.Round]
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Trivia (%s)** · Question %d/%d\n%s\n", t.Pack, t.Round+1, len(t.Rounds), r.Question)
+	buttons := make([]discordgo.MessageComponent, len(r.Choices))
+	for k, c := range r.Choices {
+		b := discordgo.Button{
+			Style:    discordgo.PrimaryButton,
+			Label:    c,
+			CustomID: fmt.Sprintf("trivia-%s-%d-%d", t.ID, t.Round, k),
+		}
+		if !t.Open {
+			b.Disabled = true
+			b.Style = discordgo.SecondaryButton
+			if k == r.Correct {
+				b.Style = discordgo.SuccessButton
+			}
+		}
+		buttons[k] = b
+	}
+	if t.Open {
+		fmt.Fprintf(&sb, "⏱ Time's up <t:%d:R>", t.Asked.Add(triviaAnswerTime).Unix())
+		return sb.String(), buttonRows(buttons)
+	}
+
+	fmt.Fprintf(&sb, "✅ **%s**\n", r.Choices[r.Correct])
+	if len(t.Gained) == 0 {
+		sb.WriteString("Nobody got it.\n")
+	}
+	for _, userID := range t.ranked(t.Gained) {
+		fmt.Fprintf(&sb, "<@%s> +%d\n", userID, t.Gained[userID])
+	}
+	if t.last() {
+		sb.WriteString("\n🏆 **Final standings**\n")
+	} else {
+		sb.WriteString("\n**Scores**\n")
+	}
+	for k, userID := range t.ranked(t.Scores) {
+		fmt.Fprintf(&sb, "%d. <@%s> %d\n", k+1, userID, t.Scores[userID])
+	}
+	if !t.last() {
+		sb.WriteString("Next question coming up…")
+	}
+	return sb.String(), buttonRows(buttons)
+}
+
+// ranked orders players by points, most first.
+func (t *trivia) ranked(points map[string]int) []string {
+	var ids []string
+	for userID, p := range points {
+		if p > 0 {
+			ids = append(ids, userID)
+		}
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		if points[ids[a]] != points[ids[b]] {
+			return points[ids[a]] > points[ids[b]]
+		}
+		return ids[a] < ids[b]
+	})
+	return ids
+}
+
+// scheduleClose ends the current question when its time is up, then posts the next one.
+func (t *trivia) scheduleClose(s *discordgo.Session) {
+	round := t.Round
+	time.AfterFunc(triviaAnswerTime, func() {
+		triviaGamesMu.Lock()
+		if triviaGames[t.ChannelID] != t || t.Round != round || !t.Open {
+			triviaGamesMu.Unlock()
+			return
+		}
+		t.close()
+		content, components := t.message()
+		last, messageID := t.last(), t.MessageID
+		if last {
+			delete(triviaGames, t.ChannelID)
+		}
+		triviaGamesMu.Unlock()
+
+		_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         messageID,
+			Channel:    t.ChannelID,
+			Content:    &content,
+			Components: &components,
+		})
+		if err != nil {
+			fmt.Println("trivia reveal edit error:", err)
+		}
+		if !last {
+			time.AfterFunc(triviaPause, func() { t.next(s) })
+		}
+	})
+}
+
+func (t *trivia) next(s *discordgo.Session) {
+	triviaGamesMu.Lock()
+	t.Round++
+	t.ask()
+	content, components := t.message()
+	triviaGamesMu.Unlock()
+
+	m, err := s.ChannelMessageSendComplex(t.ChannelID, &discordgo.MessageSend{
+		Content:    content,
+		Components: components,
+	})
+	if err != nil {
+		fmt.Println("trivia question send error:", err)
+	}
+	triviaGamesMu.Lock()
+	if m != nil {
+		t.MessageID = m.ID
+	}
+	t.scheduleClose(s)
+	triviaGamesMu.Unlock()
+}
+
+func handleTrivia(s *discordgo.Session, i *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
+	if len(options) == 0 {
+		return
+	}
+	sub := options[0]
+	om := parseOptions(sub.Options)
+
+	switch sub.Name {
+	case "start":
+		rounds := triviaDefaultRounds
+		if opt, ok := om["rounds"]; ok {
+			rounds = min(max(int(opt.IntValue()), 1), triviaMaxRounds)
+		}
+		var name string
+		if opt, ok := om["pack"]; ok {
+			name = opt.StringValue()
+		}
+		pack := findTriviaPack(i.GuildID, name)
+		if pack == nil || len(pack.Questions) == 0 {
+			respondEphemeral(s, i, fmt.Sprintf("There's no trivia pack called %q. Use `/trivia packs` to see them.", name))
+			return
+		}
+
+		triviaGamesMu.Lock()
+		if triviaGames[i.ChannelID] != nil {
+			triviaGamesMu.Unlock()
+			respondEphemeral(s, i, "There's already a trivia game in this channel.")
+			return
+		}
+		t := newTrivia(i.ChannelID, pack, rounds)
+		t.ask()
+		triviaGames[i.ChannelID] = t
+		content, components := t.message()
+		triviaGamesMu.Unlock()
+
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:    content,
+				Components: components,
+			},
+		})
+		if err != nil {
+			fmt.Println("handleTrivia respond error:", err)
+		}
+		m, err := s.InteractionResponse(i.Interaction)
+		triviaGamesMu.Lock()
+		if err == nil {
+			t.MessageID = m.ID
+		}
+		t.scheduleClose(s)
+		triviaGamesMu.Unlock()
+
+	case "packs":
+		var sb strings.Builder
+		sb.WriteString("**Trivia packs**\n")
+		for _, p := range triviaPacks(i.GuildID) {
+			fmt.Fprintf(&sb, "`%s` · %d questions\n", p.Name, len(p.Questions))
+		}
+		sb.WriteString("Upload your own with `/trivia upload`. A pack is a JSON file like " +
+			"`{\"name\": \"mypack\", \"questions\": [{\"question\": \"…\", \"answers\": [\"right\", \"wrong\", …]}]}`.")
+		respondEphemeral(s, i, sb.String())
+
+	case "upload":
+		opt, ok := om["file"]
+		if !ok {
+			return
+		}
+		att := i.ApplicationCommandData().Resolved.Attachments[opt.Value.(string)]
+		pack, err := downloadTriviaPack(att)
+		if err == nil {
+			for _, p := range embeddedTriviaPacks {
+				if strings.EqualFold(p.Name, pack.Name) {
+					err = fmt.Errorf("%q is the name of a built-in pack.", pack.Name)
+				}
+			}
+		}
+		if err == nil {
+			if err = addGuildTriviaPack(i.GuildID, pack); err != nil {
+				fmt.Println("trivia save error:", err)
+			}
+		}
+		if err != nil {
+			respondEphemeral(s, i, "Couldn't add that pack: "+err.Error())
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("Added `%s` with %d questions. Play it with `/trivia start pack:%s`.", pack.Name, len(pack.Questions), pack.Name))
+	}
+}
+
+var triviaHTTP = &http.Client{Timeout: 10 * time.Second}
+
+func downloadTriviaPack(att *discordgo.MessageAttachment) (*triviaPack, error) {
+	if att == nil {
+		return nil, errors.New("no file was attached.")
+	}
+	if att.Size > triviaMaxUpload {
+		return nil, fmt.Errorf("the file is larger than %d KB.", triviaMaxUpload>>10)
+	}
+	resp, err := triviaHTTP.Get(att.URL)
+	if err != nil {
+		fmt.Println("trivia download error:", err)
+		return nil, errors.New("the file couldn't be downloaded.")
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, triviaMaxUpload))
+	if err != nil {
+		return nil, errors.New("the file couldn't be downloaded.")
+	}
+	pack := &triviaPack{}
+	if err := json.Unmarshal(b, pack); err != nil {
+		return nil, fmt.Errorf("it isn't valid JSON (%v).", err)
+	}
+	if pack.Name == "" {
+		pack.Name = strings.TrimSuffix(att.Filename, path.Ext(att.Filename))
+	}
+	if err := pack.validate(); err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// handleTriviaAnswer records a player's answer to the open question. Answers stay
+// private until the question closes.
+func handleTriviaAnswer(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
+	parts := strings.Split(customID, "-")
+	if len(parts) != 4 {
+		return
+	}
+	id := parts[1]
+	round, _ := strconv.Atoi(parts[2])
+	choice, _ := strconv.Atoi(parts[3])
+	userID := interactionUserID(i)
+
+	triviaGamesMu.Lock()
+	t := triviaGames[i.ChannelID]
+	var reply string
+	switch {
+	case t == nil || t.ID != id || t.Round != round || !t.Open:
+		reply = "That question has closed."
+	case choice < 0 || choice >= len(t.Rounds[round].Choices):
+		reply = "That isn't one of the answers."
+	default:
+		if _, ok := t.Answers[userID]; ok {
+			reply = "You've already locked in an answer."
+			break
+		}
+		t.Answers[userID] = triviaAnswer{Choice: choice, At: time.Now()}
+		reply = fmt.Sprintf("Locked in **%s**.", t.Rounds[round].Choices[choice])
+	}
+	triviaGamesMu.Unlock()
+	respondEphemeral(s, i, reply)
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	bj "geofbot/blackjack"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var unoKind = &gameKind{
+	name:       "uno",
+	title:      "Uno",
+	minPlayers: 2,
+	maxPlayers: 8,
+	againLabel: "New game",
+	peekLabel:  "My hand",
+	newGame: func(players []string) Game {
+		g := &uno{Players: players, Hands: make([][]string, len(players)), Dir: 1}
+		g.Deck = newUnoDeck()
+		g.Deck.Shuffle()
+		for k := range players {
+			g.draw(k, unoHandSize)
+		}
+		// Start the discard pile with a coloured card so there's a colour to follow.
+		for {
+			var card string
+			card, g.Deck = g.Deck.Deal()
+			g.Discard = append(g.Discard, card)
+			if c, _ := unoSplit(card); c != "wild" {
+				g.Color = c
+				break
+			}
+		}
+		return g
+	},
+	decode: decodeGame[uno],
+}
+
+const unoHandSize = 7
+
+var unoColors = []string{"red", "yellow", "green", "blue"}
+
+var unoColorEmoji = map[string]string{"red": "🟥", "yellow": "🟨", "green": "🟩", "blue": "🟦", "wild": "⬛"}
+
+var unoValues = []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "skip", "reverse", "+2"}
+
+// newUnoDeck returns the 108 card deck. Cards are a colour and a value, e.g.
+// "red 7" or "blue skip"; wilds are "wild" and "wild +4".
+func newUnoDeck() bj.Deck {
+	var d bj.Deck
+	for _, c := range unoColors {
+		for _, v := range unoValues {
+			d = append(d, c+" "+v)
+			if v != "0" {
+				d = append(d, c+" "+v)
+			}
+		}
+	}
+	for range 4 {
+		d = append(d, "wild", "wild +4")
+	}
+	return d
+}
+
+func unoSplit(card string) (color, value string) {
+	color, value, _ = strings.Cut(card, " ")
+	return color, value
+}
+
+func unoLabel(card string) string {
+	c, v := unoSplit(card)
+	if c == "wild" {
+		return strings.TrimSpace(unoColorEmoji[c] + " Wild " + v)
+	}
+	switch v {
+	case "skip":
+		v = "Skip"
+	case "reverse":
+		v = "Reverse"
+	}
+	return unoColorEmoji[c] + " " + v
+}
+
+type uno struct {
+	Players []string
+	Hands   [][]string
+	Deck    bj.Deck
+	Discard []string
+	// Color is the colour to follow, which a wild sets.
+	Color string
+	Turn  int
+	Dir   int
+	// Drawn is set once the player to move has drawn their one card this turn.
+	Drawn bool
+	// Uno is set when the player to move has called Uno before playing down to one card.
+	Uno    bool
+	Winner string
+	Log    []string
+}
+
+func (g *uno) players() []string { return g.Players }
+
+func (g *uno) turn() string { return g.Players[g.Turn] }
+
+func (g *uno) seat(playerID string) int {
+	return slices.Index(g.Players, playerID)
+}
+
+func (g *uno) top() string {
+	return g.Discard[len(g.Discard)-1]
+}
+
+func (g *uno) logf(format string, args ...any) {
+	g.Log = append(g.Log, fmt.Sprintf(format, args...))
+	if len(g.Log) > 4 {
+		g.Log = g.Log[len(g.Log)-4:]
+	}
+}
+
+// draw gives a player n cards, reshuffling the discard pile into the deck when it runs out.
+func (g *uno) draw(seat, n int) {
+	for range n {
+		if len(g.Deck) == 0 {
+			if len(g.Discard) <= 1 {
+				return
+			}
+			g.Deck = slices.Clone(g.Discard[:len(g.Discard)-1])
+			g.Discard = g.Discard[len(g.Discard)-1:]
+			g.Deck.Shuffle()
+		}
+		var card string
+		card, g.Deck = g.Deck.Deal()
+		g.Hands[seat] = append(g.Hands[seat], card)
+	}
+}
+
+func (g *uno) playable(card string) bool {
+	c, v := unoSplit(card)
+	_, topValue := unoSplit(g.top())
+	return c == "wild" || c == g.Color || v == topValue
+}
+
+func (g *uno) next(steps int) int {
+	n := len(g.Players)
+	return ((g.Turn+g.Dir*steps)%n + n) % n
+}
+
+// actions is empty: everything happens from each player's private hand.
+func (g *uno) actions() []action { return nil }
+
+func (g *uno) apply(playerID, actionID string) error {
+	verb, value, _ := strings.Cut(actionID, ":")
+	seat := g.seat(playerID)
+	hand := g.Hands[seat]
+	switch {
+	case verb == "uno":
+		if len(hand) != 2 {
+			return errors.New("Call Uno when you're about to play down to your last card.")
+		}
+		g.Uno = true
+		g.logf("<@%s> calls **Uno!**", playerID)
+		return nil
+
+	case verb == "draw":
+		if g.Drawn {
+			return errors.New("You've already drawn this turn.")
+		}
+		g.draw(seat, 1)
+		g.Drawn = true
+		if card := g.Hands[seat][len(g.Hands[seat])-1]; !g.playable(card) {
+			g.logf("<@%s> draws a card and passes.", playerID)
+			g.endTurn(1)
+		}
+		return nil
+
+	case verb == "pass":
+		if !g.Drawn {
+			return errors.New("Draw a card before passing.")
+		}
+		g.logf("<@%s> draws a card and passes.", playerID)
+		g.endTurn(1)
+		return nil
+
+	case strings.HasPrefix(verb, "p"):
+		card, color, _ := strings.Cut(value, "|")
+		k := slices.Index(hand, card)
+		if k < 0 {
+			return errors.New("You don't have that card.")
+		}
+		if !g.playable(card) {
+			return errors.New("That card doesn't match the colour or value on top.")
+		}
+		c, v := unoSplit(card)
+		if c == "wild" {
+			if !slices.Contains(unoColors, color) {
+				return errors.New("Pick a colour for your wild.")
+			}
+			c = color
+		}
+		g.Hands[seat] = slices.Delete(hand, k, k+1)
+		g.Discard = append(g.Discard, card)
+		g.Color = c
+		g.logf("<@%s> plays %s", playerID, unoLabel(card))
+		if color != "" {
+			g.Log[len(g.Log)-1] += " and picks " + unoColorEmoji[color] + " " + color
+		}
+		if len(g.Hands[seat]) == 0 {
+			g.Winner = playerID
+			return nil
+		}
+		if len(g.Hands[seat]) == 1 && !g.Uno {
+			g.draw(seat, 2)
+			g.logf("<@%s> forgot to call Uno and draws 2.", playerID)
+		}
+
+		switch v {
+		case "skip":
+			g.endTurn(2)
+		case "reverse":
+			g.Dir = -g.Dir
+			// With two players a reverse works like a skip.
+			if len(g.Players) == 2 {
+				g.endTurn(2)
+			} else {
+				g.endTurn(1)
+			}
+		case "+2", "+4":
+			victim := g.next(1)
+			n := 2
+			if v == "+4" {
+				n = 4
+			}
+			g.draw(victim, n)
+			g.logf("<@%s> draws %d and is skipped.", g.Players[victim], n)
+			g.endTurn(2)
+		default:
+			g.endTurn(1)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown uno action %q", actionID)
+}
+
+func (g *uno) endTurn(steps int) {
+	g.Turn = g.next(steps)
+	g.Drawn, g.Uno = false, false
+}
+
+func (g *uno) outcome() (bool, string) {
+	return g.Winner != "", g.Winner
+}
+
+func (g *uno) render() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Uno** · Top card: %s", unoLabel(g.top()))
+	if c, _ := unoSplit(g.top()); c == "wild" {
+		fmt.Fprintf(&sb, " (colour: %s %s)", unoColorEmoji[g.Color], g.Color)
+	}
+	arrow := "⬇️"
+	if g.Dir < 0 {
+		arrow = "⬆️"
+	}
+	fmt.Fprintf(&sb, " · Play order %s\n", arrow)
+	for k, p := range g.Players {
+		marker := "▫️"
+		if k == g.Turn && g.Winner == "" {
+			marker = "▶️"
+		}
+		fmt.Fprintf(&sb, "%s <@%s> · %d cards", marker, p, len(g.Hands[k]))
+		if len(g.Hands[k]) == 1 {
+			sb.WriteString(" · **UNO!**")
+		}
+		sb.WriteString("\n")
+	}
+	for _, line := range g.Log {
+		sb.WriteString("\n" + line)
+	}
+	if g.Winner != "" {
+		fmt.Fprintf(&sb, "\n🎉 <@%s> wins!", g.Winner)
+	} else {
+		fmt.Fprintf(&sb, "\n<@%s>, it's your turn. Press **My hand** to play.", g.turn())
+	}
+	return sb.String()
+}
+
+// privateView shows a player their hand and, on their turn, what they can play.
+func (g *uno) privateView(playerID string) (string, []action) {
+	seat := g.seat(playerID)
+	hand := slices.Clone(g.Hands[seat])
+	slices.SortFunc(hand, func(a, b string) int {
+		ca, va := unoSplit(a)
+		cb, vb := unoSplit(b)
+		if ca != cb {
+			return slices.Index(unoColors, ca) - slices.Index(unoColors, cb)
+		}
+		if va != vb {
+			return slices.Index(unoValues, va) - slices.Index(unoValues, vb)
+		}
+		return strings.Compare(a, b)
+	})
+	labels := make([]string, len(hand))
+	for k, card := range hand {
+		labels[k] = unoLabel(card)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Top card: %s · colour %s %s\nYour hand (%d): %s", unoLabel(g.top()), unoColorEmoji[g.Color], g.Color, len(hand), strings.Join(labels, ", "))
+	if g.Players[g.Turn] != playerID {
+		fmt.Fprintf(&sb, "\nIt's <@%s>'s turn.", g.turn())
+		return sb.String(), nil
+	}
+
+	var opts []discordgo.SelectMenuOption
+	for _, card := range slices.Compact(hand) {
+		if !g.playable(card) {
+			continue
+		}
+		if c, _ := unoSplit(card); c == "wild" {
+			for _, color := range unoColors {
+				opts = append(opts, discordgo.SelectMenuOption{
+					Label: unoLabel(card) + " → " + unoColorEmoji[color] + " " + color,
+					Value: card + "|" + color,
+				})
+			}
+			continue
+		}
+		opts = append(opts, discordgo.SelectMenuOption{Label: unoLabel(card), Value: card})
+	}
+	var actions []action
+	for start := 0; start < len(opts) && start < 75; start += 25 {
+		actions = append(actions, action{ID: fmt.Sprintf("p%d", start/25), Label: "Play a card", Choices: opts[start:min(start+25, len(opts))]})
+	}
+	if len(opts) == 0 {
+		sb.WriteString("\nYou have nothing to play.")
+	}
+	if g.Drawn {
+		actions = append(actions, action{ID: "pass", Label: "Pass", Style: discordgo.SecondaryButton})
+	} else {
+		actions = append(actions, action{ID: "draw", Label: "Draw a card", Style: discordgo.PrimaryButton})
+	}
+	if len(hand) == 2 && !g.Uno {
+		actions = append(actions, action{ID: "uno", Label: "Uno!", Style: discordgo.DangerButton})
+	}
+	return sb.String(), actions
+}
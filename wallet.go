@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	walletFile    = "wallets.json"
+	startingChips = 1000
+	dailyChips    = 250
+	maxWager      = 10000
+)
+
+// wallet is a user's chips, shared by every casino game.
+type wallet struct {
+	Chips int
+	// BlackjackBet is staked on every blackjack hand the user is dealt.
+	BlackjackBet int
+	LastDaily    time.Time
+}
+
+// Bet options take the address of their minimum.
+var zeroChips, oneChip = 0.0, 1.0
+
+var (
+	// wallets maps user ID to wallet.
+	wallets   map[string]*wallet
+	walletsMu sync.Mutex
+)
+
+func loadWallets() {
+	walletsMu.Lock()
+	defer walletsMu.Unlock()
+	wallets = make(map[string]*wallet)
+	if err := loadJSON(walletFile, &wallets); err != nil {
+		fmt.Println("loadWallets error:", err)
+	}
+}
+
+// userWallet returns a user's wallet, opening one with the starting chips if
+// they've never played. Callers must hold walletsMu.
+func userWallet(userID string) *wallet {
+	w, ok := wallets[userID]
+	if !ok {
+		w = &wallet{Chips: startingChips}
+		wallets[userID] = w
+	}
+	return w
+}
+
+func saveWallets() {
+	if err := saveJSON(walletFile, wallets); err != nil {
+		fmt.Println("wallet save error:", err)
+	}
+}
+
+func walletBalance(userID string) int {
+	walletsMu.Lock()
+	defer walletsMu.Unlock()
+	return userWallet(userID).Chips
+}
+
+// walletDebit takes a stake from a user, failing if they can't cover it.
+func walletDebit(userID string, amount int) error {
+	walletsMu.Lock()
+	defer walletsMu.Unlock()
+	w := userWallet(userID)
+	if amount <= 0 {
+		return errors.New("Bets must be at least 1 chip.")
+	}
+	if w.Chips < amount {
+		return fmt.Errorf("You only have %d chips.", w.Chips)
+	}
+	w.Chips -= amount
+	saveWallets()
+	return nil
+}
+
+func walletCredit(userID string, amount int) {
+	walletsMu.Lock()
+	defer walletsMu.Unlock()
+	userWallet(userID).Chips += amount
+	saveWallets()
+}
+
+func handleWallet(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+	walletsMu.Lock()
+	w := userWallet(userID)
+	content := fmt.Sprintf("💰 You have **%d** chips.", w.Chips)
+	if w.BlackjackBet > 0 {
+		content += fmt.Sprintf("\nYour blackjack bet is %d chips a hand.", w.BlackjackBet)
+	}
+	if next := w.LastDaily.AddDate(0, 0, 1); time.Now().Before(next) {
+		content += fmt.Sprintf("\nYour next daily chips are ready <t:%d:R>.", next.Unix())
+	} else {
+		content += "\nUse `/daily` to collect your daily chips."
+	}
+	walletsMu.Unlock()
+	respondEphemeral(s, i, content)
+}
+
+// handleDaily hands out free chips once every 24 hours so nobody is broke for good.
+func handleDaily(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+	walletsMu.Lock()
+	w := userWallet(userID)
+	var content string
+	if next := w.LastDaily.AddDate(0, 0, 1); time.Now().Before(next) {
+		content = fmt.Sprintf("You've already collected today. Come back <t:%d:R>.", next.Unix())
+	} else {
+		w.Chips += dailyChips
+		w.LastDaily = time.Now()
+		saveWallets()
+		content = fmt.Sprintf("💰 +%d chips! You now have **%d**.", dailyChips, w.Chips)
+	}
+	walletsMu.Unlock()
+	respondEphemeral(s, i, content)
+}
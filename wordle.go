@@ -0,0 +1,288 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+//go:embed words/wordle.txt
+var wordleWordList string
+
+var wordleWords = strings.Fields(wordleWordList)
+
+const (
+	wordleFile     = "wordle.json"
+	wordleMaxTries = 6
+)
+
+// wordleEpoch is the day of puzzle #1.
+var wordleEpoch = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// wordleStats is one user's Wordle history in one guild.
+type wordleStats struct {
+	Day        int
+	Guesses    []string
+	Played     int
+	Wins       int
+	Streak     int
+	MaxStreak  int
+	LastWinDay int
+	// Distribution counts wins by the number of guesses they took.
+	Distribution [wordleMaxTries]int
+}
+
+var (
+	// wordleGuilds maps guild ID to user ID to that user's stats.
+	wordleGuilds   map[string]map[string]*wordleStats
+	wordleGuildsMu sync.Mutex
+)
+
+func loadWordle() {
+	wordleGuildsMu.Lock()
+	defer wordleGuildsMu.Unlock()
+	wordleGuilds = make(map[string]map[string]*wordleStats)
+	if err := loadJSON(wordleFile, &wordleGuilds); err != nil {
+		fmt.Println("loadWordle error:", err)
+	}
+}
+
+func wordleDay(now time.Time) int {
+	return int(now.UTC().Sub(wordleEpoch).Hours()/24) + 1
+}
+
+// wordleAnswer picks the day's word for a guild, so each guild gets its own daily puzzle.
+func wordleAnswer(guildID string, day int) string {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s/%d", guildID, day)
+	return wordleWords[h.Sum32()%uint32(len(wordleWords))]
+}
+
+// wordleScore marks each letter of guess green, yellow or grey. Letters that
+// appear more often in the guess than in the answer only score as many times
+// as the answer has them.
+func wordleScore(guess, answer string) string {
+	marks := []string{"⬛", "⬛", "⬛", "⬛", "⬛"}
+	var remaining [26]int
+	for k := range answer {
+		if guess[k] == answer[k] {
+			marks[k] = "🟩"
+		} else {
+			remaining[answer[k]-'a']++
+		}
+	}
+	for k := range guess {
+		if marks[k] == "⬛" && remaining[guess[k]-'a'] > 0 {
+			marks[k] = "🟨"
+			remaining[guess[k]-'a']--
+		}
+	}
+	return strings.Join(marks, "")
+}
+
+// wordleUserStats returns a user's stats, rolling them over to today and breaking the
+// streak if they missed yesterday's puzzle. Callers must hold wordleGuildsMu.
+func wordleUserStats(guildID, userID string, day int) *wordleStats {
+	users, ok := wordleGuilds[guildID]
+	if !ok {
+		users = make(map[string]*wordleStats)
+		wordleGuilds[guildID] = users
+	}
+	st, ok := users[userID]
+	if !ok {
+		st = &wordleStats{}
+		users[userID] = st
+	}
+	if st.Day != day {
+		st.Day = day
+		st.Guesses = nil
+	}
+	if st.LastWinDay < day-1 {
+		st.Streak = 0
+	}
+	return st
+}
+
+func (st *wordleStats) solved(answer string) bool {
+	return len(st.Guesses) > 0 && st.Guesses[len(st.Guesses)-1] == answer
+}
+
+func (st *wordleStats) done(answer string) bool {
+	return st.solved(answer) || len(st.Guesses) >= wordleMaxTries
+}
+
+// guess records a guess and updates the stats once the puzzle is finished.
+func (st *wordleStats) guess(word, answer string) error {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if len(word) != 5 || strings.Trim(word, "abcdefghijklmnopqrstuvwxyz") != "" {
+		return errors.New("Guesses must be five letters.")
+	}
+	if st.done(answer) {
+		return errors.New("You've already finished today's puzzle.")
+	}
+	st.Guesses = append(st.Guesses, word)
+	if !st.done(answer) {
+		return nil
+	}
+	st.Played++
+	if st.solved(answer) {
+		st.Wins++
+		st.Streak++
+		st.MaxStreak = max(st.MaxStreak, st.Streak)
+		st.LastWinDay = st.Day
+		st.Distribution[len(st.Guesses)-1]++
+	} else {
+		st.Streak = 0
+	}
+	return nil
+}
+
+// squares returns the colored rows without letters, safe to share before others have played.
+func (st *wordleStats) squares(answer string) string {
+	rows := make([]string, len(st.Guesses))
+	for k, g := range st.Guesses {
+		rows[k] = wordleScore(g, answer)
+	}
+	return strings.Join(rows, "\n")
+}
+
+func (st *wordleStats) tries(answer string) string {
+	if st.solved(answer) {
+		return fmt.Sprintf("%d/%d", len(st.Guesses), wordleMaxTries)
+	}
+	return fmt.Sprintf("X/%d", wordleMaxTries)
+}
+
+func (st *wordleStats) render(answer string) (string, []discordgo.MessageComponent) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**Wordle #%d**\n", st.Day)
+	for _, g := range st.Guesses {
+		fmt.Fprintf(&sb, "%s `%s`\n", wordleScore(g, answer), strings.ToUpper(g))
+	}
+	for range wordleMaxTries - len(st.Guesses) {
+		sb.WriteString("⬜⬜⬜⬜⬜\n")
+	}
+	if !st.done(answer) {
+		return sb.String(), []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{Style: discordgo.PrimaryButton, Label: "Guess", CustomID: "wordle-guess"},
+			}},
+		}
+	}
+	if st.solved(answer) {
+		fmt.Fprintf(&sb, "\n🎉 Solved in %s!", st.tries(answer))
+	} else {
+		fmt.Fprintf(&sb, "\nThe word was **%s**.", strings.ToUpper(answer))
+	}
+	fmt.Fprintf(&sb, "\nPlayed %d · Won %d · Streak %d · Best streak %d", st.Played, st.Wins, st.Streak, st.MaxStreak)
+	return sb.String(), []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Style: discordgo.SuccessButton, Label: "Share", CustomID: "wordle-share"},
+		}},
+	}
+}
+
+// handleWordle shows the user today's puzzle privately, since the letters would spoil it for everyone else.
+func handleWordle(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	day := wordleDay(time.Now())
+	answer := wordleAnswer(i.GuildID, day)
+	wordleGuildsMu.Lock()
+	content, components := wordleUserStats(i.GuildID, interactionUserID(i), day).render(answer)
+	wordleGuildsMu.Unlock()
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: components,
+			Flags:      discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		fmt.Println("handleWordle respond error:", err)
+	}
+}
+
+// handleWordleInteraction handles the Guess and Share buttons and the guess modal.
+func handleWordleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
+	userID := interactionUserID(i)
+	day := wordleDay(time.Now())
+	answer := wordleAnswer(i.GuildID, day)
+
+	switch customID {
+	case "wordle-guess":
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseModal,
+			Data: &discordgo.InteractionResponseData{
+				CustomID: "wordle-submit",
+				Title:    fmt.Sprintf("Wordle #%d", day),
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  "value",
+							Label:     "Your guess",
+							Style:     discordgo.TextInputShort,
+							Required:  true,
+							MinLength: 5,
+							MaxLength: 5,
+						},
+					}},
+				},
+			},
+		})
+		if err != nil {
+			fmt.Println("wordle modal respond error:", err)
+		}
+
+	case "wordle-submit":
+		wordleGuildsMu.Lock()
+		st := wordleUserStats(i.GuildID, userID, day)
+		err := st.guess(modalValue(i.ModalSubmitData(), "value"), answer)
+		content, components := st.render(answer)
+		if err == nil {
+			if err := saveJSON(wordleFile, wordleGuilds); err != nil {
+				fmt.Println("wordle save error:", err)
+			}
+		}
+		wordleGuildsMu.Unlock()
+		if err != nil {
+			respondEphemeral(s, i, err.Error())
+			return
+		}
+		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:    content,
+				Components: components,
+			},
+		})
+		if err != nil {
+			fmt.Println("wordle guess respond error:", err)
+		}
+
+	case "wordle-share":
+		wordleGuildsMu.Lock()
+		st := wordleUserStats(i.GuildID, userID, day)
+		var content string
+		if st.done(answer) {
+			content = fmt.Sprintf("<@%s> — Wordle #%d %s 🔥%d\n%s", userID, day, st.tries(answer), st.Streak, st.squares(answer))
+		}
+		wordleGuildsMu.Unlock()
+		if content == "" {
+			respondEphemeral(s, i, "Finish today's puzzle before sharing it.")
+			return
+		}
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: content},
+		})
+		if err != nil {
+			fmt.Println("wordle share respond error:", err)
+		}
+	}
+}